@@ -2,13 +2,478 @@ package kap
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"io"
+	"io/fs"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
+// maxFileNameBytes bounds saved file names, leaving room for a collision
+// suffix within the 255-byte limit of common file systems.
+const maxFileNameBytes = 200
+
+// DefaultDownloadConcurrency is the number of attachments DownloadAll
+// fetches at once when its concurrency argument is zero.
+const DefaultDownloadConcurrency = 4
+
+// attachmentPathPrefix is the path of the download endpoint, which
+// attachment URLs end with followed by the ID.
+const attachmentPathPrefix = "/api/vyk/downloadAttachment/"
+
+// Attachment describes an attachment saved by SaveAttachment.
+type Attachment struct {
+	ID string
+
+	// FileName is the name the server gave the file, decoded from the
+	// Content-Disposition header and made safe for use as a file name.
+	FileName string
+
+	// Path is where the file was written. Its base name differs from
+	// FileName when a file of that name already existed.
+	Path string
+
+	ContentType string
+	Size        int64
+
+	// SHA256 is the hex-encoded SHA-256 digest of the content.
+	SHA256 string
+}
+
 // DownloadAttachment downloads a disclosure attachment by its ID. It returns
 // the response body as an io.ReadCloser, the Content-Disposition header
 // value, and any error. The caller must close the returned ReadCloser.
+// With WithBlockList, blocked attachments are refused with ErrBlocked.
 func (c *Client) DownloadAttachment(ctx context.Context, id string) (io.ReadCloser, string, error) {
-	path := "/api/vyk/downloadAttachment/" + id
-	return c.getRaw(ctx, path, nil)
+	if err := c.checkAttachment(ctx, id); err != nil {
+		return nil, "", err
+	}
+	path := attachmentPathPrefix + id
+	return c.getRaw(ctx, "DownloadAttachment", path, nil)
+}
+
+// ID returns the attachment ID at the end of a.URL, for use with
+// DownloadAttachment. The URL must be an absolute http or https URL whose
+// path ends in /downloadAttachment/{id}.
+func (a AttachmentURL) ID() (string, error) {
+	u, err := url.Parse(strings.TrimSpace(a.URL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("kap: invalid attachment URL %q", a.URL)
+	}
+	i := strings.LastIndex(u.Path, "/downloadAttachment/")
+	if i < 0 {
+		return "", fmt.Errorf("kap: attachment URL %q has no downloadAttachment path", a.URL)
+	}
+	id := strings.TrimSuffix(u.Path[i+len("/downloadAttachment/"):], "/")
+	if !validAttachmentID(id) {
+		return "", fmt.Errorf("kap: attachment URL %q has an invalid ID", a.URL)
+	}
+	return id, nil
+}
+
+// validAttachmentID reports whether id is a non-empty run of letters,
+// digits, "-" and "_".
+func validAttachmentID(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, r := range id {
+		if !(r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)) || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// DownloadAttachmentURL downloads the attachment a links to. The URL
+// itself is not fetched; its ID is passed to DownloadAttachment.
+func (c *Client) DownloadAttachmentURL(ctx context.Context, a AttachmentURL) (io.ReadCloser, string, error) {
+	id, err := a.ID()
+	if err != nil {
+		return nil, "", err
+	}
+	return c.DownloadAttachment(ctx, id)
+}
+
+// DownloadAll saves every attachment of detail into dir as SaveAttachment
+// does, fetching up to concurrency at once (DefaultDownloadConcurrency if
+// zero or less). The attachment's FileName is used when the response has
+// no file name. The results are in the order of detail.AttachmentURLs; an
+// attachment that failed has a nil result and its error is included in
+// the returned error.
+func (c *Client) DownloadAll(ctx context.Context, detail *DisclosureDetail, dir string, concurrency int) ([]*Attachment, error) {
+	if concurrency <= 0 {
+		concurrency = DefaultDownloadConcurrency
+	}
+
+	results := make([]*Attachment, len(detail.AttachmentURLs))
+	errs := forEach(ctx, len(detail.AttachmentURLs), concurrency, func(i int) error {
+		a := detail.AttachmentURLs[i]
+		id, err := a.ID()
+		if err != nil {
+			return err
+		}
+		results[i], err = c.saveAttachment(ctx, id, dir, a.FileName)
+		return err
+	})
+	return results, errors.Join(errs...)
+}
+
+// SaveAttachment downloads the attachment with the given ID into dir,
+// which is created if needed. The file is named after the
+// Content-Disposition filename, including RFC 5987 "filename*" values with
+// Turkish characters, reduced to a safe base name; without one the ID is
+// used. If the name is taken, " (1)", " (2)" and so on are added before
+// the extension. The content is written to a temporary file first, so the
+// file appears complete or not at all.
+func (c *Client) SaveAttachment(ctx context.Context, id, dir string) (*Attachment, error) {
+	return c.saveAttachment(ctx, id, dir, "")
+}
+
+// saveAttachment is SaveAttachment with a file name to use when the
+// response has none.
+func (c *Client) saveAttachment(ctx context.Context, id, dir, fallback string) (*Attachment, error) {
+	if err := c.checkAttachment(ctx, id); err != nil {
+		return nil, err
+	}
+	resp, err := c.doRequest(ctx, "DownloadAttachment", attachmentPathPrefix+id, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck // read errors are reported by the copy
+
+	a := &Attachment{ID: id, ContentType: resp.Header.Get("Content-Type")}
+	name := contentDispositionFilename(resp.Header.Get("Content-Disposition"))
+	if name == "" {
+		name = fallback
+	}
+	if name == "" {
+		name = id + extensionFor(a.ContentType)
+	}
+	a.FileName = sanitizeFileName(name, id)
+
+	hash := sha256.New()
+	a.Path, a.Size, err = writeNewFile(dir, a.FileName, io.TeeReader(resp.Body, hash))
+	if err != nil {
+		return nil, fmt.Errorf("kap: saving attachment %s: %w", id, err)
+	}
+	a.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	return a, nil
+}
+
+// extensionFor returns the usual file extension for a content type, or ""
+// if it is unknown.
+func extensionFor(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	if mediaType == "application/pdf" {
+		return ".pdf"
+	}
+	if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
+
+// writeNewFile streams r to a temporary file in dir and then gives it the
+// first free name among name, "name (1)", "name (2)" and so on. Existing
+// files are never replaced.
+func writeNewFile(dir, name string, r io.Reader) (string, int64, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", 0, err
+	}
+	tmp, err := os.CreateTemp(dir, ".attachment-*.tmp")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // the file is gone after a successful link or rename
+
+	size, err := io.Copy(tmp, r)
+	if err != nil {
+		tmp.Close() //nolint:errcheck // the copy error takes precedence
+		return "", 0, err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close() //nolint:errcheck // the sync error takes precedence
+		return "", 0, err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", 0, err
+	}
+
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for n := 0; n < 10000; n++ {
+		candidate := name
+		if n > 0 {
+			candidate = stem + " (" + strconv.Itoa(n) + ")" + ext
+		}
+		path := filepath.Join(dir, candidate)
+		if filepath.Dir(path) != filepath.Clean(dir) {
+			return "", 0, fmt.Errorf("unsafe file name %q", candidate)
+		}
+		err := claimPath(tmp.Name(), path)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		return path, size, err
+	}
+	return "", 0, fmt.Errorf("no free file name for %q", name)
+}
+
+// claimPath moves the file at tmp to path unless path exists. A hard link
+// makes the check and the move atomic; where links are not supported, path
+// is reserved with an exclusive create and then replaced by a rename.
+func claimPath(tmp, path string) error {
+	err := os.Link(tmp, path)
+	if err == nil || errors.Is(err, fs.ErrExist) {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	f.Close() //nolint:errcheck // the empty placeholder is replaced below
+	return os.Rename(tmp, path)
+}
+
+// windowsReserved are device names Windows does not allow as file names,
+// with or without an extension.
+var windowsReserved = []string{
+	"CON", "PRN", "AUX", "NUL",
+	"COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8", "COM9",
+	"LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9",
+}
+
+// sanitizeFileName reduces name to a base name that is safe on Unix and
+// Windows: directory parts, control characters and reserved characters are
+// removed, reserved device names are prefixed, and the length is bounded.
+// It returns fallback if nothing usable remains.
+func sanitizeFileName(name, fallback string) string {
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r == utf8.RuneError, unicode.IsControl(r), strings.ContainsRune(`<>:"|?*`, r):
+			return -1
+		case unicode.IsSpace(r):
+			return ' '
+		}
+		return r
+	}, name)
+	name = strings.Trim(strings.Join(strings.Fields(name), " "), ". ")
+	if name == "" {
+		if fallback == "" {
+			return "attachment"
+		}
+		return sanitizeFileName(fallback, "")
+	}
+
+	stem := strings.TrimSuffix(name, filepath.Ext(name))
+	if slices.Contains(windowsReserved, strings.ToUpper(stem)) {
+		name = "_" + name
+	}
+
+	if len(name) > maxFileNameBytes {
+		ext := filepath.Ext(name)
+		if len(ext) > 20 {
+			ext = ""
+		}
+		stem := name[:maxFileNameBytes-len(ext)]
+		for !utf8.ValidString(stem) {
+			stem = stem[:len(stem)-1]
+		}
+		name = strings.TrimRight(stem, ". ") + ext
+	}
+	return name
+}
+
+// contentDispositionFilename returns the file name in a Content-Disposition
+// header. An RFC 5987 "filename*" value, possibly split into RFC 2231
+// continuations, takes precedence over "filename". UTF-8, ISO-8859-9,
+// Windows-1254 and ISO-8859-1 are decoded, as are MIME encoded-words and
+// raw Windows-1254 bytes in a plain "filename".
+func contentDispositionFilename(header string) string {
+	params := dispositionParams(header)
+
+	if v, ok := params["filename*"]; ok {
+		if s, ok := decodeExtValue(v); ok && s != "" {
+			return s
+		}
+	}
+	if s := joinContinuations(params); s != "" {
+		return s
+	}
+
+	v := params["filename"]
+	if strings.HasPrefix(v, "=?") {
+		var dec mime.WordDecoder
+		if s, err := dec.DecodeHeader(v); err == nil {
+			return s
+		}
+	}
+	if strings.Contains(v, "%") {
+		if s, err := url.PathUnescape(v); err == nil && utf8.ValidString(s) {
+			v = s
+		}
+	}
+	return decodeText([]byte(v))
+}
+
+// dispositionParams splits the parameters of a Content-Disposition header
+// into a map with lower-cased names and unquoted values. It accepts the
+// malformed headers servers commonly send, such as unquoted spaces.
+func dispositionParams(header string) map[string]string {
+	params := make(map[string]string)
+	for _, part := range splitParams(header) {
+		name, value, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+			value = unquote(value[1 : len(value)-1])
+		}
+		if _, dup := params[name]; !dup {
+			params[name] = value
+		}
+	}
+	return params
+}
+
+// splitParams splits s at semicolons outside quoted strings.
+func splitParams(s string) []string {
+	var parts []string
+	quoted, escaped, start := false, false, 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case escaped:
+			escaped = false
+		case c == '\\' && quoted:
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case c == ';' && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// unquote removes backslash escapes from the content of a quoted string.
+func unquote(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// decodeExtValue decodes an RFC 5987 ext-value: charset'language'value
+// with the value percent-encoded.
+func decodeExtValue(v string) (string, bool) {
+	charset, rest, ok := strings.Cut(v, "'")
+	if !ok {
+		return "", false
+	}
+	_, encoded, ok := strings.Cut(rest, "'")
+	if !ok {
+		return "", false
+	}
+	data, err := percentDecode(encoded)
+	if err != nil {
+		return "", false
+	}
+	return decodeCharset(charset, data), true
+}
+
+// joinContinuations assembles an RFC 2231 "filename*0", "filename*1*", ...
+// sequence.
+func joinContinuations(params map[string]string) string {
+	var charset string
+	var data []byte
+	for n := 0; ; n++ {
+		key := "filename*" + strconv.Itoa(n)
+		if v, ok := params[key]; ok {
+			data = append(data, v...)
+			continue
+		}
+		v, ok := params[key+"*"]
+		if !ok {
+			break
+		}
+		if n == 0 {
+			cs, rest, _ := strings.Cut(v, "'")
+			_, v, _ = strings.Cut(rest, "'")
+			charset = cs
+		}
+		decoded, err := percentDecode(v)
+		if err != nil {
+			return ""
+		}
+		data = append(data, decoded...)
+	}
+	if len(data) == 0 {
+		return ""
+	}
+	return decodeCharset(charset, data)
+}
+
+// percentDecode decodes %XX escapes, leaving other bytes, including "+",
+// unchanged.
+func percentDecode(s string) ([]byte, error) {
+	data := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' {
+			data = append(data, s[i])
+			continue
+		}
+		if i+2 >= len(s) {
+			return nil, errors.New("truncated escape")
+		}
+		b, err := hex.DecodeString(s[i+1 : i+3])
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, b[0])
+		i += 2
+	}
+	return data, nil
+}
+
+// decodeCharset converts data in the named charset to a string. Unknown
+// charsets are treated like UTF-8, falling back to Windows-1254.
+func decodeCharset(charset string, data []byte) string {
+	switch strings.ToLower(strings.TrimSpace(charset)) {
+	case "iso-8859-9", "windows-1254", "cp1254", "latin5":
+		return decodeWindows1254(data)
+	case "iso-8859-1", "latin1":
+		r := make([]rune, len(data))
+		for i, c := range data {
+			r[i] = rune(c)
+		}
+		return string(r)
+	}
+	return decodeText(data)
 }
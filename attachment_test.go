@@ -0,0 +1,236 @@
+package kap
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestContentDispositionFilename(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{`attachment; filename="report.pdf"`, "report.pdf"},
+		{`attachment; filename=plain.pdf`, "plain.pdf"},
+		{`attachment; filename="a\"b.pdf"`, `a"b.pdf`},
+		{`attachment; filename="name; with semicolon.pdf"`, "name; with semicolon.pdf"},
+		{`attachment; filename*=UTF-8''Faaliyet%20Raporu%20%C5%9E.pdf`, "Faaliyet Raporu Ş.pdf"},
+		{`attachment; filename="fallback.pdf"; filename*=UTF-8''real%C4%B1.pdf`, "realı.pdf"},
+		{`attachment; filename*=ISO-8859-9''%DEirket.pdf`, "Şirket.pdf"},
+		{`attachment; filename*=windows-1254''%DEirket.pdf`, "Şirket.pdf"},
+		{`attachment; filename*0="part1"; filename*1="part2.pdf"`, "part1part2.pdf"},
+		{`attachment; filename*0*=UTF-8''%C5%9F; filename*1=".pdf"`, "ş.pdf"},
+		// Path parts are kept here and removed by sanitizeFileName.
+		{`attachment; filename="../../etc/passwd"`, "../../etc/passwd"},
+		{`inline`, ""},
+		{``, ""},
+	}
+	for _, tt := range tests {
+		if got := contentDispositionFilename(tt.header); got != tt.want {
+			t.Errorf("contentDispositionFilename(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeFileName(t *testing.T) {
+	long := strings.Repeat("ş", 150) + ".pdf"
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"rapor ş.pdf", "rapor ş.pdf"},
+		{"../../etc/passwd", "passwd"},
+		{`..\..\win.ini`, "win.ini"},
+		{"/abs/path.txt", "path.txt"},
+		{`C:\Windows\x.pdf`, "x.pdf"},
+		{"CON", "_CON"},
+		{"con.txt", "_con.txt"},
+		{"LPT1.pdf", "_LPT1.pdf"},
+		{"a:b*c?.pdf", "abc.pdf"},
+		{"x\x00y\ty.pdf", "xyy.pdf"},
+		{"a   b.pdf", "a b.pdf"},
+		{" .hidden. ", "hidden"},
+		{"", "ID"},
+		{".", "ID"},
+		{"..", "ID"},
+		{"\x00\x01", "ID"},
+		{long, strings.Repeat("ş", 98) + ".pdf"},
+	}
+	for _, tt := range tests {
+		got := sanitizeFileName(tt.name, "ID")
+		if got != tt.want {
+			t.Errorf("sanitizeFileName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+		if len(got) > maxFileNameBytes {
+			t.Errorf("sanitizeFileName(%q) is %d bytes", tt.name, len(got))
+		}
+	}
+	if got := sanitizeFileName("", ""); got != "attachment" {
+		t.Errorf(`sanitizeFileName("", "") = %q, want "attachment"`, got)
+	}
+}
+
+func TestAttachmentURLID(t *testing.T) {
+	tests := []struct {
+		url    string
+		want   string
+		wantOK bool
+	}{
+		{"https://www.kap.org.tr/tr/api/file/downloadAttachment/4028328d8a1b", "4028328d8a1b", true},
+		{"https://apigwdev.mkk.com.tr/api/vyk/downloadAttachment/abc_12-3/", "abc_12-3", true},
+		{"/api/vyk/downloadAttachment/abc", "", false},
+		{"ftp://host/downloadAttachment/abc", "", false},
+		{"https://host/other/abc", "", false},
+		{"https://host/downloadAttachment/", "", false},
+		{"https://host/downloadAttachment/../x", "", false},
+	}
+	for _, tt := range tests {
+		got, err := AttachmentURL{URL: tt.url}.ID()
+		if (err == nil) != tt.wantOK || got != tt.want {
+			t.Errorf("ID(%q) = %q, %v; want %q, ok %v", tt.url, got, err, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestWriteNewFileCollision(t *testing.T) {
+	dir := t.TempDir()
+	for i, want := range []string{"a.pdf", "a (1).pdf", "a (2).pdf"} {
+		path, size, err := writeNewFile(dir, "a.pdf", strings.NewReader("v"+string(rune('0'+i))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if filepath.Base(path) != want || size != 2 {
+			t.Errorf("write %d = %s, %d; want %s, 2", i, path, size, want)
+		}
+	}
+	if b, err := os.ReadFile(filepath.Join(dir, "a.pdf")); err != nil || string(b) != "v0" {
+		t.Errorf("a.pdf = %q, %v; want v0", b, err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil || len(entries) != 3 {
+		t.Errorf("dir has %d entries, %v; want 3 with no temporary files", len(entries), err)
+	}
+}
+
+func TestSaveAttachment(t *testing.T) {
+	const body = "%PDF-1.4 test"
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/vyk/downloadAttachment/named":
+			w.Header().Set("Content-Disposition", `attachment; filename*=UTF-8''..%2F%C3%96zel%20Durum.pdf`)
+		case "/api/vyk/downloadAttachment/unnamed":
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte(body)) //nolint:errcheck // test server
+	})
+	dir := t.TempDir()
+	sum := sha256.Sum256([]byte(body))
+
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"named", "Özel Durum.pdf"},
+		{"unnamed", "unnamed.pdf"},
+	}
+	for _, tt := range tests {
+		a, err := c.SaveAttachment(context.Background(), tt.id, dir)
+		if err != nil {
+			t.Fatalf("SaveAttachment(%s): %v", tt.id, err)
+		}
+		if a.FileName != tt.want || a.Path != filepath.Join(dir, tt.want) {
+			t.Errorf("SaveAttachment(%s) = %s at %s, want %s", tt.id, a.FileName, a.Path, tt.want)
+		}
+		if a.Size != int64(len(body)) || a.SHA256 != hex.EncodeToString(sum[:]) || a.ContentType != "application/pdf" {
+			t.Errorf("SaveAttachment(%s) = %+v", tt.id, a)
+		}
+	}
+
+	if _, err := c.SaveAttachment(context.Background(), "missing", dir); err == nil {
+		t.Error("SaveAttachment(missing) succeeded")
+	}
+}
+
+func TestDownloadAttachmentURL(t *testing.T) {
+	var paths []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Write([]byte("data")) //nolint:errcheck // test server
+	})
+
+	// Only the ID is taken from the URL; the host is never contacted.
+	rc, _, err := c.DownloadAttachmentURL(context.Background(), AttachmentURL{URL: "https://other.example/x/downloadAttachment/abc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc.Close() //nolint:errcheck // test
+	if len(paths) != 1 || paths[0] != "/api/vyk/downloadAttachment/abc" {
+		t.Errorf("requested %q, want the downloadAttachment endpoint", paths)
+	}
+
+	if _, _, err := c.DownloadAttachmentURL(context.Background(), AttachmentURL{URL: "https://host/other/abc"}); err == nil {
+		t.Error("DownloadAttachmentURL with an invalid URL succeeded")
+	}
+	if len(paths) != 1 {
+		t.Errorf("an invalid URL was requested: %q", paths)
+	}
+}
+
+func TestDownloadAll(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimPrefix(r.URL.Path, attachmentPathPrefix)
+		switch id {
+		case "named":
+			w.Header().Set("Content-Disposition", `attachment; filename="server.pdf"`)
+		case "missing":
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte(id)) //nolint:errcheck // test server
+	})
+	const base = "https://www.kap.org.tr/api/file/downloadAttachment/"
+	detail := &DisclosureDetail{AttachmentURLs: []AttachmentURL{
+		{URL: base + "named", FileName: "ignored.pdf"},
+		{URL: base + "unnamed", FileName: "Listed Name.pdf"},
+		{URL: "not a url", FileName: "bad.pdf"},
+		{URL: base + "missing", FileName: "missing.pdf"},
+		{URL: base + "bare"},
+	}}
+	dir := t.TempDir()
+
+	got, err := c.DownloadAll(context.Background(), detail, dir, 2)
+	if err == nil {
+		t.Fatal("DownloadAll succeeded despite an invalid URL and a missing attachment")
+	}
+	if !strings.Contains(err.Error(), "not a url") {
+		t.Errorf("error %q does not mention the invalid URL", err)
+	}
+	if len(got) != len(detail.AttachmentURLs) {
+		t.Fatalf("got %d results, want %d", len(got), len(detail.AttachmentURLs))
+	}
+
+	want := []string{"server.pdf", "Listed Name.pdf", "", "", "bare.pdf"}
+	for i, name := range want {
+		switch {
+		case name == "" && got[i] != nil:
+			t.Errorf("result %d = %+v, want nil for a failed attachment", i, got[i])
+		case name != "" && (got[i] == nil || got[i].FileName != name):
+			t.Errorf("result %d = %+v, want file %s", i, got[i], name)
+		case name != "":
+			b, err := os.ReadFile(got[i].Path)
+			if id := got[i].ID; err != nil || string(b) != id {
+				t.Errorf("result %d content = %q, %v; want %q", i, b, err, id)
+			}
+		}
+	}
+}
@@ -0,0 +1,384 @@
+package kap
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotStored is returned by AttachmentStore Get and Stat for IDs the
+// store does not hold.
+var ErrNotStored = errors.New("kap: attachment not in store")
+
+// AttachmentInfo is the metadata an AttachmentStore keeps with each
+// attachment.
+type AttachmentInfo struct {
+	ID string `json:"id"`
+
+	// FileName is the original file name, from the Content-Disposition
+	// header or the disclosure detail. It is not sanitized.
+	FileName string `json:"fileName,omitempty"`
+
+	// DisclosureIndex is the disclosure the attachment was mirrored from.
+	DisclosureIndex int `json:"disclosureIndex,omitempty"`
+
+	ContentType  string    `json:"contentType,omitempty"`
+	Size         int64     `json:"size"`
+	SHA256       string    `json:"sha256"`
+	DownloadedAt time.Time `json:"downloadedAt"`
+}
+
+// AttachmentStore holds attachment content keyed by attachment ID, so that
+// attachments can be served from the distributor's own storage as KAP
+// requires. Content is stored once per SHA-256 digest, however many IDs
+// refer to it.
+type AttachmentStore interface {
+	// Put stores the content read from r under info.ID, replacing any
+	// attachment stored under that ID. Size and SHA256 are computed from
+	// the content and DownloadedAt is set to the current time if zero. Put
+	// returns the stored metadata.
+	Put(ctx context.Context, info AttachmentInfo, r io.Reader) (AttachmentInfo, error)
+
+	// Get returns the content and metadata of the attachment with the
+	// given ID, or ErrNotStored. The caller must close the ReadCloser.
+	Get(ctx context.Context, id string) (io.ReadCloser, AttachmentInfo, error)
+
+	// Stat returns the metadata of the attachment with the given ID, or
+	// ErrNotStored.
+	Stat(ctx context.Context, id string) (AttachmentInfo, error)
+
+	// List returns the metadata of every stored attachment, ordered by ID.
+	List(ctx context.Context) ([]AttachmentInfo, error)
+}
+
+// MemoryAttachmentStore is an AttachmentStore that keeps attachments in
+// memory. It suits tests and short-lived processes.
+type MemoryAttachmentStore struct {
+	mu    sync.Mutex
+	infos map[string]AttachmentInfo
+	blobs map[string][]byte // by SHA-256
+}
+
+// NewMemoryAttachmentStore creates an empty in-memory attachment store.
+func NewMemoryAttachmentStore() *MemoryAttachmentStore {
+	return &MemoryAttachmentStore{
+		infos: make(map[string]AttachmentInfo),
+		blobs: make(map[string][]byte),
+	}
+}
+
+// Put stores the content read from r.
+func (s *MemoryAttachmentStore) Put(_ context.Context, info AttachmentInfo, r io.Reader) (AttachmentInfo, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return AttachmentInfo{}, fmt.Errorf("kap: reading attachment %s: %w", info.ID, err)
+	}
+	sum := sha256.Sum256(data)
+	info.SHA256 = hex.EncodeToString(sum[:])
+	info.Size = int64(len(data))
+	if info.DownloadedAt.IsZero() {
+		info.DownloadedAt = time.Now().UTC()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, replaced := s.infos[info.ID]
+	s.infos[info.ID] = info
+	if _, ok := s.blobs[info.SHA256]; !ok {
+		s.blobs[info.SHA256] = data
+	}
+	if replaced && old.SHA256 != info.SHA256 {
+		s.release(old.SHA256)
+	}
+	return info, nil
+}
+
+// release drops the content with the given digest if no ID refers to it.
+// The caller holds s.mu.
+func (s *MemoryAttachmentStore) release(digest string) {
+	for _, info := range s.infos {
+		if info.SHA256 == digest {
+			return
+		}
+	}
+	delete(s.blobs, digest)
+}
+
+// Get returns a stored attachment.
+func (s *MemoryAttachmentStore) Get(_ context.Context, id string) (io.ReadCloser, AttachmentInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info, ok := s.infos[id]
+	if !ok {
+		return nil, AttachmentInfo{}, fmt.Errorf("%w: %s", ErrNotStored, id)
+	}
+	return io.NopCloser(bytes.NewReader(s.blobs[info.SHA256])), info, nil
+}
+
+// Stat returns the metadata of a stored attachment.
+func (s *MemoryAttachmentStore) Stat(_ context.Context, id string) (AttachmentInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info, ok := s.infos[id]
+	if !ok {
+		return AttachmentInfo{}, fmt.Errorf("%w: %s", ErrNotStored, id)
+	}
+	return info, nil
+}
+
+// List returns the metadata of every stored attachment.
+func (s *MemoryAttachmentStore) List(_ context.Context) ([]AttachmentInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]AttachmentInfo, 0, len(s.infos))
+	for _, info := range s.infos {
+		list = append(list, info)
+	}
+	slices.SortFunc(list, func(a, b AttachmentInfo) int { return strings.Compare(a.ID, b.ID) })
+	return list, nil
+}
+
+// FileAttachmentStore is an AttachmentStore in a directory. Content is
+// kept in "objects/xx/{sha256}", named after its digest, and metadata in
+// a JSON sidecar "meta/{id}.json". Both are written atomically via rename.
+// Content that no longer has an ID referring to it is not removed.
+type FileAttachmentStore struct {
+	dir string
+}
+
+// NewFileAttachmentStore creates an attachment store in dir. The
+// directory is created on first Put.
+func NewFileAttachmentStore(dir string) *FileAttachmentStore {
+	return &FileAttachmentStore{dir: dir}
+}
+
+// objectPath returns the path of the content with the given digest.
+func (s *FileAttachmentStore) objectPath(digest string) string {
+	return filepath.Join(s.dir, "objects", digest[:2], digest)
+}
+
+// metaPath returns the path of the sidecar for id, which must be a valid
+// attachment ID so that it cannot name a file outside the store.
+func (s *FileAttachmentStore) metaPath(id string) (string, error) {
+	if !validAttachmentID(id) {
+		return "", fmt.Errorf("kap: invalid attachment ID %q", id)
+	}
+	return filepath.Join(s.dir, "meta", id+".json"), nil
+}
+
+// Put stores the content read from r.
+func (s *FileAttachmentStore) Put(_ context.Context, info AttachmentInfo, r io.Reader) (AttachmentInfo, error) {
+	meta, err := s.metaPath(info.ID)
+	if err != nil {
+		return AttachmentInfo{}, err
+	}
+	objects := filepath.Join(s.dir, "objects")
+	if err := os.MkdirAll(objects, 0o750); err != nil {
+		return AttachmentInfo{}, fmt.Errorf("kap: creating attachment store: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(objects, ".object-*.tmp")
+	if err != nil {
+		return AttachmentInfo{}, fmt.Errorf("kap: storing attachment %s: %w", info.ID, err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // the file is gone after a successful rename
+
+	hash := sha256.New()
+	info.Size, err = io.Copy(io.MultiWriter(tmp, hash), r)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if err != nil {
+		tmp.Close() //nolint:errcheck // the copy error takes precedence
+		return AttachmentInfo{}, fmt.Errorf("kap: storing attachment %s: %w", info.ID, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return AttachmentInfo{}, fmt.Errorf("kap: storing attachment %s: %w", info.ID, err)
+	}
+	info.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	if info.DownloadedAt.IsZero() {
+		info.DownloadedAt = time.Now().UTC()
+	}
+
+	object := s.objectPath(info.SHA256)
+	if _, err := os.Stat(object); errors.Is(err, fs.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(object), 0o750); err != nil {
+			return AttachmentInfo{}, fmt.Errorf("kap: storing attachment %s: %w", info.ID, err)
+		}
+		if err := os.Rename(tmp.Name(), object); err != nil {
+			return AttachmentInfo{}, fmt.Errorf("kap: storing attachment %s: %w", info.ID, err)
+		}
+	} else if err != nil {
+		return AttachmentInfo{}, fmt.Errorf("kap: storing attachment %s: %w", info.ID, err)
+	}
+
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return AttachmentInfo{}, fmt.Errorf("kap: encoding attachment metadata: %w", err)
+	}
+	if err := writeFileAtomic(meta, data, 0o600); err != nil {
+		return AttachmentInfo{}, fmt.Errorf("kap: writing attachment metadata: %w", err)
+	}
+	return info, nil
+}
+
+// Get opens a stored attachment.
+func (s *FileAttachmentStore) Get(ctx context.Context, id string) (io.ReadCloser, AttachmentInfo, error) {
+	info, err := s.Stat(ctx, id)
+	if err != nil {
+		return nil, AttachmentInfo{}, err
+	}
+	f, err := os.Open(s.objectPath(info.SHA256))
+	if err != nil {
+		return nil, AttachmentInfo{}, fmt.Errorf("kap: opening attachment %s: %w", id, err)
+	}
+	return f, info, nil
+}
+
+// Stat reads the sidecar of a stored attachment.
+func (s *FileAttachmentStore) Stat(_ context.Context, id string) (AttachmentInfo, error) {
+	meta, err := s.metaPath(id)
+	if err != nil {
+		return AttachmentInfo{}, err
+	}
+	return readAttachmentInfo(meta)
+}
+
+// List reads every sidecar in the store.
+func (s *FileAttachmentStore) List(_ context.Context) ([]AttachmentInfo, error) {
+	entries, err := os.ReadDir(filepath.Join(s.dir, "meta"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("kap: listing attachment store: %w", err)
+	}
+	list := make([]AttachmentInfo, 0, len(entries))
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || strings.HasPrefix(name, ".") || filepath.Ext(name) != ".json" {
+			continue
+		}
+		info, err := readAttachmentInfo(filepath.Join(s.dir, "meta", name))
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, info)
+	}
+	slices.SortFunc(list, func(a, b AttachmentInfo) int { return strings.Compare(a.ID, b.ID) })
+	return list, nil
+}
+
+// readAttachmentInfo decodes the sidecar at path.
+func readAttachmentInfo(path string) (AttachmentInfo, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path is built from a validated ID
+	if errors.Is(err, fs.ErrNotExist) {
+		id := strings.TrimSuffix(filepath.Base(path), ".json")
+		return AttachmentInfo{}, fmt.Errorf("%w: %s", ErrNotStored, id)
+	}
+	if err != nil {
+		return AttachmentInfo{}, fmt.Errorf("kap: reading attachment metadata: %w", err)
+	}
+	var info AttachmentInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return AttachmentInfo{}, fmt.Errorf("kap: decoding attachment metadata %s: %w", path, err)
+	}
+	return info, nil
+}
+
+// SyncAttachments mirrors every attachment of details into store, fetching
+// up to concurrency at once (DefaultDownloadConcurrency if zero or less).
+// Attachments already in the store are not downloaded again, and an
+// attachment listed by several disclosures is stored once, under the
+// first. Blocked attachments are skipped. SyncAttachments returns the
+// number of attachments stored and the joined errors of those that
+// failed.
+func (c *Client) SyncAttachments(ctx context.Context, store AttachmentStore, details []*DisclosureDetail, concurrency int) (int, error) {
+	if concurrency <= 0 {
+		concurrency = DefaultDownloadConcurrency
+	}
+
+	type job struct {
+		id, fileName string
+		index        int
+	}
+	var (
+		jobs []job
+		errs []error
+	)
+	seen := make(map[string]bool)
+	for _, d := range details {
+		for _, a := range d.AttachmentURLs {
+			id, err := a.ID()
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if !seen[id] {
+				seen[id] = true
+				jobs = append(jobs, job{id, a.FileName, int(d.DisclosureIndex)})
+			}
+		}
+	}
+
+	saved := make([]bool, len(jobs))
+	errs = append(errs, forEach(ctx, len(jobs), concurrency, func(i int) error {
+		var err error
+		saved[i], err = c.syncAttachment(ctx, store, jobs[i].id, jobs[i].fileName, jobs[i].index)
+		return err
+	})...)
+	stored := 0
+	for _, s := range saved {
+		if s {
+			stored++
+		}
+	}
+	return stored, errors.Join(errs...)
+}
+
+// syncAttachment stores the attachment with the given ID unless the store
+// already holds it or it is blocked, and reports whether it was stored.
+func (c *Client) syncAttachment(ctx context.Context, store AttachmentStore, id, fileName string, index int) (bool, error) {
+	if _, err := store.Stat(ctx, id); err == nil {
+		return false, nil
+	} else if !errors.Is(err, ErrNotStored) {
+		return false, err
+	}
+	if err := c.checkAttachment(ctx, id); errors.Is(err, ErrBlocked) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	resp, err := c.doRequest(ctx, "DownloadAttachment", attachmentPathPrefix+id, nil)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close() //nolint:errcheck // read errors are reported by Put
+
+	if name := contentDispositionFilename(resp.Header.Get("Content-Disposition")); name != "" {
+		fileName = name
+	}
+	info := AttachmentInfo{
+		ID:              id,
+		FileName:        fileName,
+		DisclosureIndex: index,
+		ContentType:     resp.Header.Get("Content-Type"),
+	}
+	if _, err := store.Put(ctx, info, resp.Body); err != nil {
+		return false, err
+	}
+	return true, nil
+}
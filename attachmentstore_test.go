@@ -0,0 +1,190 @@
+package kap
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testAttachmentStore runs the checks shared by every AttachmentStore.
+func testAttachmentStore(t *testing.T, s AttachmentStore) {
+	t.Helper()
+	ctx := context.Background()
+
+	if _, err := s.Stat(ctx, "a"); !errors.Is(err, ErrNotStored) {
+		t.Errorf("Stat of empty store: %v, want ErrNotStored", err)
+	}
+	if _, _, err := s.Get(ctx, "a"); !errors.Is(err, ErrNotStored) {
+		t.Errorf("Get of empty store: %v, want ErrNotStored", err)
+	}
+
+	when := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	info, err := s.Put(ctx, AttachmentInfo{ID: "b", FileName: "b.pdf", DisclosureIndex: 7, DownloadedAt: when}, strings.NewReader("same"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size != 4 || info.SHA256 != "0967115f2813a3541eaef77de9d9d5773f1c0c04314b0bbfe4ff3b3b1c55b5d5" || !info.DownloadedAt.Equal(when) {
+		t.Errorf("Put = %+v", info)
+	}
+	a, err := s.Put(ctx, AttachmentInfo{ID: "a"}, strings.NewReader("same"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.DownloadedAt.IsZero() {
+		t.Error("Put did not set DownloadedAt")
+	}
+
+	rc, got, err := s.Get(ctx, "b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close() //nolint:errcheck // test
+	if err != nil || string(data) != "same" || got.FileName != "b.pdf" || got.DisclosureIndex != 7 {
+		t.Errorf("Get = %q, %+v, %v", data, got, err)
+	}
+
+	// Replacing a's content leaves b's shared content intact.
+	if _, err := s.Put(ctx, AttachmentInfo{ID: "a"}, strings.NewReader("new")); err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[string]string{"a": "new", "b": "same"} {
+		rc, _, err := s.Get(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close() //nolint:errcheck // test
+		if string(data) != want {
+			t.Errorf("Get(%s) = %q, want %q", id, data, want)
+		}
+	}
+
+	list, err := s.List(ctx)
+	if err != nil || len(list) != 2 || list[0].ID != "a" || list[1].ID != "b" {
+		t.Errorf("List = %+v, %v; want a and b in order", list, err)
+	}
+}
+
+func TestMemoryAttachmentStore(t *testing.T) {
+	s := NewMemoryAttachmentStore()
+	testAttachmentStore(t, s)
+	if len(s.blobs) != 2 {
+		t.Errorf("store holds %d blobs, want 2", len(s.blobs))
+	}
+}
+
+func TestFileAttachmentStore(t *testing.T) {
+	dir := t.TempDir()
+	s := NewFileAttachmentStore(dir)
+	if list, err := s.List(context.Background()); err != nil || len(list) != 0 {
+		t.Errorf("List of new store = %v, %v", list, err)
+	}
+	testAttachmentStore(t, s)
+
+	// Content is stored once per digest; unreferenced content stays.
+	objects, err := filepath.Glob(filepath.Join(dir, "objects", "*", "*"))
+	if err != nil || len(objects) != 2 {
+		t.Errorf("objects = %v, %v; want 2", objects, err)
+	}
+	if tmp, _ := filepath.Glob(filepath.Join(dir, "objects", ".*")); len(tmp) != 0 {
+		t.Errorf("temporary files left behind: %v", tmp)
+	}
+
+	for _, id := range []string{"", "../x", "a/b", "a.json"} {
+		if _, err := s.Put(context.Background(), AttachmentInfo{ID: id}, strings.NewReader("x")); err == nil {
+			t.Errorf("Put(%q) succeeded", id)
+		}
+		if _, err := s.Stat(context.Background(), id); err == nil || errors.Is(err, ErrNotStored) {
+			t.Errorf("Stat(%q) = %v, want an invalid ID error", id, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "x.json")); err == nil {
+		t.Error("Put wrote outside the store")
+	}
+}
+
+func TestSyncAttachments(t *testing.T) {
+	var (
+		mu         sync.Mutex
+		downloaded []string
+	)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/vyk/blockedDisclosures" {
+			w.Write([]byte(`[{"disclosureIndex":1,"attachmentIds":["blocked"]}]`)) //nolint:errcheck // test server
+			return
+		}
+		id := strings.TrimPrefix(r.URL.Path, attachmentPathPrefix)
+		mu.Lock()
+		downloaded = append(downloaded, id)
+		mu.Unlock()
+		if id == "missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Disposition", `attachment; filename="`+id+`.pdf"`)
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte("content " + id)) //nolint:errcheck // test server
+	}, WithBlockList(time.Hour))
+
+	const base = "https://www.kap.org.tr/api/file/downloadAttachment/"
+	store := NewMemoryAttachmentStore()
+	if _, err := store.Put(context.Background(), AttachmentInfo{ID: "old"}, strings.NewReader("old")); err != nil {
+		t.Fatal(err)
+	}
+	details := []*DisclosureDetail{
+		{DisclosureIndex: 1, AttachmentURLs: []AttachmentURL{
+			{URL: base + "a", FileName: "listed.pdf"},
+			{URL: base + "old"},
+			{URL: base + "blocked"},
+		}},
+		{DisclosureIndex: 2, AttachmentURLs: []AttachmentURL{
+			{URL: base + "a"},
+			{URL: base + "b"},
+			{URL: base + "missing"},
+			{URL: "bad"},
+		}},
+	}
+
+	stored, err := c.SyncAttachments(context.Background(), store, details, 2)
+	if stored != 2 {
+		t.Errorf("stored = %d, want 2", stored)
+	}
+	if err == nil || !strings.Contains(err.Error(), `"bad"`) || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("error = %v, want the invalid URL and the missing attachment", err)
+	}
+
+	mu.Lock()
+	got := strings.Join(slices.Sorted(slices.Values(downloaded)), ",")
+	mu.Unlock()
+	if got != "a,b,missing" {
+		t.Errorf("downloaded %s, want a,b,missing once each and no stored or blocked attachments", got)
+	}
+
+	info, err := store.Stat(context.Background(), "a")
+	if err != nil || info.DisclosureIndex != 1 || info.FileName != "a.pdf" || info.ContentType != "application/pdf" {
+		t.Errorf("Stat(a) = %+v, %v; want the first disclosure and the server's file name", info, err)
+	}
+	if _, err := store.Stat(context.Background(), "blocked"); !errors.Is(err, ErrNotStored) {
+		t.Errorf("blocked attachment stored: %v", err)
+	}
+
+	// A second sync finds everything stored and downloads nothing new.
+	mu.Lock()
+	downloaded = nil
+	mu.Unlock()
+	stored, _ = c.SyncAttachments(context.Background(), store, details, 0)
+	mu.Lock()
+	defer mu.Unlock()
+	if stored != 0 || len(downloaded) != 1 || downloaded[0] != "missing" {
+		t.Errorf("second sync stored %d, downloaded %v; want 0 and only the missing attachment retried", stored, downloaded)
+	}
+}
@@ -2,24 +2,170 @@ package kap
 
 import (
 	"context"
+	"errors"
 	"net/url"
+	"time"
 )
 
+const (
+	// generateTokenPath is the endpoint used to obtain bearer tokens.
+	generateTokenPath = "/auth/generateToken"
+
+	// tokenLifetime is how long a bearer token stays valid after it is
+	// generated. It is used when the token carries no "exp" claim.
+	tokenLifetime = 24 * time.Hour
+
+	// tokenRefreshMargin is how long before expiry a token is proactively
+	// regenerated.
+	tokenRefreshMargin = 5 * time.Minute
+)
+
+// tokenRefresh tracks a single in-flight token generation shared by all
+// callers that need a new token at the same time.
+type tokenRefresh struct {
+	done  chan struct{}
+	token Token
+	err   error
+}
+
 // GenerateToken requests a new bearer token from the KAP API using the
 // client's API key. The token is stored on the client and used for all
 // subsequent requests. It returns the token string.
+//
+// Calling GenerateToken is optional: when the client has an API key it
+// generates a token on first use, regenerates it shortly before it expires,
+// and regenerates it once and replays the request when the API rejects it.
+// When a TokenStore is configured, a valid token from the store is reused
+// instead of generating a new one.
 func (c *Client) GenerateToken(ctx context.Context) (string, error) {
+	t, err := c.refreshToken(ctx, c.Token().Raw)
+	return t.Raw, err
+}
+
+// generateToken calls the token endpoint without touching client state.
+func (c *Client) generateToken(ctx context.Context) (Token, error) {
 	params := url.Values{}
 	params.Set("apiKey", c.apiKey)
 
 	var resp TokenResponse
-	if err := c.get(ctx, "/auth/generateToken", params, &resp); err != nil {
-		return "", err
+	if err := c.get(ctx, "GenerateToken", generateTokenPath, params, &resp); err != nil {
+		return Token{}, err
+	}
+	return newToken(resp.Token, time.Now()), nil
+}
+
+// refreshToken replaces the stale token and stores the result on the
+// client. Concurrent callers share a single in-flight refresh. The refresh
+// itself is not cancelled when ctx is; it is bounded by the HTTP client
+// timeout.
+func (c *Client) refreshToken(ctx context.Context, stale string) (Token, error) {
+	c.mu.Lock()
+	r := c.refresh
+	if r == nil {
+		r = &tokenRefresh{done: make(chan struct{})}
+		c.refresh = r
+		go c.runRefresh(context.WithoutCancel(ctx), r, stale)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-r.done:
+		return r.token, r.err
+	case <-ctx.Done():
+		return Token{}, ctx.Err()
+	}
+}
+
+// runRefresh obtains a new token for r and publishes the result.
+func (c *Client) runRefresh(ctx context.Context, r *tokenRefresh, stale string) {
+	if c.tokenStore != nil {
+		r.token, r.err = c.sharedToken(ctx, stale)
+	} else {
+		r.token, r.err = c.generateToken(ctx)
 	}
 
 	c.mu.Lock()
-	c.token = resp.Token
+	if r.err == nil {
+		c.token = r.token
+	}
+	c.refresh = nil
 	c.mu.Unlock()
 
-	return resp.Token, nil
+	close(r.done)
+}
+
+// sharedToken returns a usable token from the token store, generating and
+// saving a new one under the store lock when the stored token is missing,
+// stale, or about to expire.
+func (c *Client) sharedToken(ctx context.Context, stale string) (Token, error) {
+	if t, err := c.tokenStore.Load(ctx); err == nil && usableToken(t, stale) {
+		return t, nil
+	}
+
+	unlock, err := c.tokenStore.Lock(ctx)
+	if err != nil {
+		return Token{}, err
+	}
+	defer unlock()
+
+	// Another client may have replaced the token while we waited.
+	t, err := c.tokenStore.Load(ctx)
+	if err != nil {
+		return Token{}, err
+	}
+	if usableToken(t, stale) {
+		return t, nil
+	}
+
+	t, err = c.generateToken(ctx)
+	if err != nil {
+		return Token{}, err
+	}
+	if err := c.tokenStore.Save(ctx, t); err != nil {
+		return Token{}, err
+	}
+	return t, nil
+}
+
+// usableToken reports whether t can be used in place of the stale token.
+func usableToken(t Token, stale string) bool {
+	return !t.IsZero() && t.Raw != stale && !t.expiresWithin(tokenRefreshMargin)
+}
+
+// canRefreshToken reports whether the client manages bearer tokens itself.
+func (c *Client) canRefreshToken() bool {
+	return c.basicAuth == nil && c.apiKey != ""
+}
+
+// bearerToken returns the token to send with a request, generating a new
+// one first when there is none or the current one is about to expire.
+func (c *Client) bearerToken(ctx context.Context) (string, error) {
+	token := c.Token()
+
+	if !c.canRefreshToken() {
+		return token.Raw, nil
+	}
+	if !token.IsZero() && !token.expiresWithin(tokenRefreshMargin) {
+		return token.Raw, nil
+	}
+	t, err := c.refreshToken(ctx, token.Raw)
+	return t.Raw, err
+}
+
+// renewToken replaces a token the API rejected. If another caller already
+// replaced stale in the meantime, the current token is kept.
+func (c *Client) renewToken(ctx context.Context, stale string) error {
+	if c.Token().Raw != stale {
+		return nil
+	}
+	_, err := c.refreshToken(ctx, stale)
+	return err
+}
+
+// isTokenError reports whether err is an API error indicating that the
+// bearer token is expired, invalid, or could not be validated.
+func isTokenError(err error) bool {
+	return errors.Is(err, ErrTokenExpired) ||
+		errors.Is(err, ErrInvalidToken) ||
+		errors.Is(err, ErrTokenValidation)
 }
@@ -0,0 +1,111 @@
+package kap
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tokenServer issues tokens "tok1", "tok2", ... and accepts only the most
+// recent one, answering other tokens with ER006.
+type tokenServer struct {
+	generated atomic.Int32
+	current   atomic.Value // string
+	delay     time.Duration
+}
+
+func (s *tokenServer) handler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == generateTokenPath {
+		time.Sleep(s.delay)
+		tok := fmt.Sprintf("tok%d", s.generated.Add(1))
+		s.current.Store(tok)
+		fmt.Fprintf(w, `{"token":%q}`, tok)
+		return
+	}
+	if cur, _ := s.current.Load().(string); r.Header.Get("Authorization") != cur {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"code":"ER006","message":"token expired"}`)
+		return
+	}
+	fmt.Fprint(w, `{"lastDisclosureIndex":"5"}`)
+}
+
+// runConcurrently calls fn from n goroutines at once.
+func runConcurrently(n int, fn func()) {
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			fn()
+		}()
+	}
+	close(start)
+	wg.Wait()
+}
+
+func TestTokenRefreshSingleflight(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string // initial token; "" generates one on first use
+	}{
+		{"first use", ""},
+		{"rejected token", "stale"},
+		{"expiring token", jwt(t, time.Now().Add(time.Minute))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := &tokenServer{delay: 20 * time.Millisecond}
+			var opts []Option
+			if tt.token != "" {
+				opts = append(opts, WithToken(tt.token))
+			}
+			c := NewClient("key", append([]Option{WithBaseURL(newServer(t, srv.handler))}, opts...)...)
+
+			runConcurrently(20, func() {
+				if _, err := c.LastDisclosureIndex(context.Background()); err != nil {
+					t.Error(err)
+				}
+			})
+			if got := srv.generated.Load(); got != 1 {
+				t.Errorf("generated %d tokens, want 1", got)
+			}
+			if got := c.Token().Raw; got != "tok1" {
+				t.Errorf("client token = %q, want tok1", got)
+			}
+		})
+	}
+}
+
+func TestTokenRefreshCallerCancelled(t *testing.T) {
+	srv := &tokenServer{delay: 100 * time.Millisecond}
+	c := NewClient("key", WithBaseURL(newServer(t, srv.handler)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := c.LastDisclosureIndex(ctx); err == nil {
+		t.Fatal("request with expired context succeeded")
+	}
+
+	// The refresh carries on for other callers.
+	if _, err := c.LastDisclosureIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := srv.generated.Load(); got != 1 {
+		t.Errorf("generated %d tokens, want 1", got)
+	}
+}
+
+// jwt returns an unsigned JWT that expires at exp.
+func jwt(t *testing.T, exp time.Time) string {
+	t.Helper()
+	claims := fmt.Sprintf(`{"iat":%d,"exp":%d}`, time.Now().Unix(), exp.Unix())
+	return "e30." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".sig"
+}
@@ -0,0 +1,361 @@
+package kap
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+const (
+	// FirstDisclosureIndex is the index of the oldest disclosure the API
+	// serves.
+	FirstDisclosureIndex = 84196
+
+	// KAP4DisclosureIndex is the first disclosure index of KAP 4.0.
+	// Earlier disclosures can only be fetched with fileType "html".
+	KAP4DisclosureIndex = 538004
+
+	// DefaultBackfillWorkers is the number of details a Backfill fetches
+	// at once when BackfillOptions.Workers is zero.
+	DefaultBackfillWorkers = 4
+)
+
+// BackfillOptions configures a Backfill. The zero value is usable.
+type BackfillOptions struct {
+	// Params filters the disclosures loaded, as for Disclosures.
+	Params *DisclosureListParams
+
+	// Start is the first disclosure index to load. Zero means
+	// FirstDisclosureIndex. If Checkpoint holds a later index, the
+	// backfill resumes after it instead.
+	Start int
+
+	// End is the last disclosure index to load. Zero means the latest
+	// disclosure when Run starts.
+	End int
+
+	// FileType is passed to DisclosureDetail for KAP 4.0 disclosures.
+	// Empty means "data". Earlier disclosures, and disclosures whose
+	// AcceptedDataFileTypes lack structured data, are fetched as "html".
+	FileType string
+
+	// Workers is the number of details fetched at once. Zero means
+	// DefaultBackfillWorkers.
+	Workers int
+
+	// Checkpoint, if set, persists the high-water mark after every page.
+	Checkpoint Checkpoint
+
+	// FailureFile, if set, is a file to which each failure is appended as
+	// a line of JSON, for RetryFailures to pick up later.
+	FailureFile string
+}
+
+// BackfillFailure records a disclosure whose detail could not be fetched.
+type BackfillFailure struct {
+	Index int       `json:"index"`
+	Error string    `json:"error"`
+	Time  time.Time `json:"time"`
+}
+
+// Backfill loads a range of historical disclosures with their details
+// into a sink. Disclosures are listed a page at a time, their details
+// fetched with bounded concurrency and handed to the sink in index order.
+// Progress is checkpointed after every page, so a backfill stopped by a
+// crash resumes from the last page it completed; the sink may therefore
+// see a few disclosures twice and should be idempotent.
+//
+// Disclosures whose detail cannot be fetched are recorded as failures and
+// skipped. Blocked disclosures are skipped silently. A Backfill is not
+// safe for concurrent use.
+type Backfill struct {
+	client   *Client
+	opts     BackfillOptions
+	hwm      int
+	failures []BackfillFailure
+}
+
+// NewBackfill creates a Backfill. opts may be nil.
+func (c *Client) NewBackfill(opts *BackfillOptions) *Backfill {
+	b := &Backfill{client: c}
+	if opts != nil {
+		b.opts = *opts
+	}
+	if b.opts.FileType == "" {
+		b.opts.FileType = "data"
+	}
+	if b.opts.Workers <= 0 {
+		b.opts.Workers = DefaultBackfillWorkers
+	}
+	return b
+}
+
+// HighWaterMark returns the highest disclosure index the backfill has
+// processed.
+func (b *Backfill) HighWaterMark() int {
+	return b.hwm
+}
+
+// Failures returns the failures recorded by this Backfill.
+func (b *Backfill) Failures() []BackfillFailure {
+	return slices.Clone(b.failures)
+}
+
+// Run loads disclosures until the end of the range, calling sink with each
+// disclosure and its detail. If sink returns an error, Run saves the
+// checkpoint up to the previous disclosure and returns the error. Run
+// returns ctx.Err() when ctx is done.
+func (b *Backfill) Run(ctx context.Context, sink func(Disclosure, *DisclosureDetail) error) error {
+	start, err := b.start(ctx)
+	if err != nil {
+		return err
+	}
+	end := b.opts.End
+	if end <= 0 {
+		if end, err = b.client.LastDisclosureIndex(ctx); err != nil {
+			return err
+		}
+	}
+
+	pager := b.client.DisclosurePager(start, end, b.opts.Params)
+	for !pager.Done() {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		page, err := pager.Next(ctx)
+		if err != nil {
+			return err
+		}
+		err = b.deliver(ctx, page, sink)
+		if err == nil {
+			b.hwm = max(b.hwm, pager.Cursor()-1)
+		}
+		if serr := b.save(ctx); err == nil {
+			err = serr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// start returns the first index to load, the later of Start and the index
+// after the saved checkpoint, and sets the high-water mark below it.
+func (b *Backfill) start(ctx context.Context) (int, error) {
+	start := b.opts.Start
+	if start <= 0 {
+		start = FirstDisclosureIndex
+	}
+	if b.opts.Checkpoint != nil {
+		saved, err := b.opts.Checkpoint.Load(ctx)
+		if err != nil {
+			return 0, err
+		}
+		start = max(start, saved+1)
+	}
+	b.hwm = start - 1
+	return start, nil
+}
+
+// deliver fetches the details of page and hands them to sink in order,
+// advancing the high-water mark past each disclosure processed.
+func (b *Backfill) deliver(ctx context.Context, page []Disclosure, sink func(Disclosure, *DisclosureDetail) error) error {
+	details, errs := b.fetch(ctx, page)
+	for i, d := range page {
+		idx := int(d.DisclosureIndex)
+		switch err := errs[i]; {
+		case err == nil:
+			if err := sink(d, details[i]); err != nil {
+				return err
+			}
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case errors.Is(err, ErrBlocked):
+		default:
+			if err := b.fail(idx, err); err != nil {
+				return err
+			}
+		}
+		b.hwm = max(b.hwm, idx)
+	}
+	return nil
+}
+
+// fetch fetches the details of page with DisclosureDetails, one call per
+// file type, each with up to Workers requests at once.
+func (b *Backfill) fetch(ctx context.Context, page []Disclosure) ([]*DisclosureDetail, []error) {
+	details := make([]*DisclosureDetail, len(page))
+	errs := make([]error, len(page))
+
+	fileTypes := make([]string, 0, 2)
+	groups := make(map[string][]int) // file type -> positions in page
+	for i, d := range page {
+		ft := b.fileType(d)
+		if _, ok := groups[ft]; !ok {
+			fileTypes = append(fileTypes, ft)
+		}
+		groups[ft] = append(groups[ft], i)
+	}
+	for _, ft := range fileTypes {
+		positions := groups[ft]
+		indices := make([]int, len(positions))
+		for k, i := range positions {
+			indices[k] = int(page[i].DisclosureIndex)
+		}
+		results := b.client.DisclosureDetails(ctx, indices, ft, &DetailsOptions{Workers: b.opts.Workers})
+		for k, i := range positions {
+			details[i], errs[i] = results[k].Detail, results[k].Err
+		}
+	}
+	return details, errs
+}
+
+// fileType returns the file type to fetch d with.
+func (b *Backfill) fileType(d Disclosure) string {
+	if int(d.DisclosureIndex) < KAP4DisclosureIndex {
+		return "html"
+	}
+	if b.opts.FileType == "data" && len(d.AcceptedDataFileTypes) > 0 &&
+		!containsFold(d.AcceptedDataFileTypes, "data") && !containsFold(d.AcceptedDataFileTypes, "presentation") {
+		return "html"
+	}
+	return b.opts.FileType
+}
+
+// fail records a failure and appends it to the failure file.
+func (b *Backfill) fail(index int, err error) error {
+	f := BackfillFailure{Index: index, Error: err.Error(), Time: time.Now().UTC()}
+	b.failures = append(b.failures, f)
+	if b.opts.FailureFile == "" {
+		return nil
+	}
+	line, err := json.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("kap: encoding backfill failure: %w", err)
+	}
+	if err := appendLine(b.opts.FailureFile, line); err != nil {
+		return fmt.Errorf("kap: writing backfill failure: %w", err)
+	}
+	return nil
+}
+
+// save stores the high-water mark in the checkpoint.
+func (b *Backfill) save(ctx context.Context) error {
+	if b.opts.Checkpoint == nil {
+		return nil
+	}
+	return b.opts.Checkpoint.Save(ctx, b.hwm)
+}
+
+// RetryFailures fetches the details of the disclosures listed in the
+// failure file again, calling sink for each one that now succeeds, and
+// rewrites the file with those that still fail. The disclosures are
+// retried in index order, each once however often it failed. Unlike Run,
+// the list filters are not applied and sink receives a Disclosure with
+// only DisclosureIndex set.
+func (b *Backfill) RetryFailures(ctx context.Context, sink func(Disclosure, *DisclosureDetail) error) error {
+	if b.opts.FailureFile == "" {
+		return errors.New("kap: backfill has no failure file")
+	}
+	failed, err := ReadBackfillFailures(b.opts.FailureFile)
+	if err != nil {
+		return err
+	}
+	var page []Disclosure
+	for _, f := range failed {
+		if !slices.ContainsFunc(page, func(d Disclosure) bool { return int(d.DisclosureIndex) == f.Index }) {
+			page = append(page, Disclosure{DisclosureIndex: Index(f.Index)})
+		}
+	}
+	slices.SortFunc(page, func(a, b Disclosure) int { return int(a.DisclosureIndex) - int(b.DisclosureIndex) })
+
+	details, errs := b.fetch(ctx, page)
+	var remaining bytes.Buffer
+	var sinkErr error
+	for i, d := range page {
+		err := errs[i]
+		if err == nil && sinkErr == nil {
+			sinkErr = sink(d, details[i])
+			if sinkErr == nil {
+				continue
+			}
+		}
+		if errors.Is(err, ErrBlocked) {
+			continue
+		}
+		if err == nil {
+			// Not delivered because sink failed; keep it for next time.
+			err = sinkErr
+		}
+		line, jerr := json.Marshal(BackfillFailure{Index: int(d.DisclosureIndex), Error: err.Error(), Time: time.Now().UTC()})
+		if jerr != nil {
+			return fmt.Errorf("kap: encoding backfill failure: %w", jerr)
+		}
+		remaining.Write(line)
+		remaining.WriteByte('\n')
+	}
+	if err := writeFileAtomic(b.opts.FailureFile, remaining.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("kap: writing backfill failures: %w", err)
+	}
+	if sinkErr != nil {
+		return sinkErr
+	}
+	return ctx.Err()
+}
+
+// ReadBackfillFailures reads the failures recorded in a failure file. A
+// missing file yields no failures.
+func ReadBackfillFailures(path string) ([]BackfillFailure, error) {
+	f, err := os.Open(path) //nolint:gosec // the path is chosen by the caller
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("kap: reading backfill failures: %w", err)
+	}
+	defer f.Close() //nolint:errcheck // read-only file
+
+	var failures []BackfillFailure
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var failure BackfillFailure
+		if err := json.Unmarshal(line, &failure); err != nil {
+			return nil, fmt.Errorf("kap: decoding backfill failures: %w", err)
+		}
+		failures = append(failures, failure)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("kap: reading backfill failures: %w", err)
+	}
+	return failures, nil
+}
+
+// appendLine appends line and a newline to the file at path, creating the
+// file and its directory if needed.
+func appendLine(path string, line []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644) //nolint:gosec // the path is chosen by the caller
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close() //nolint:errcheck // the write error takes precedence
+		return err
+	}
+	return f.Close()
+}
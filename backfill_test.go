@@ -0,0 +1,198 @@
+package kap
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// backfillServer serves disclosures from..to, failing the details of the
+// indices in failing and blocking those in blocked.
+func backfillServer(from, to int, failing, blocked []int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/lastDisclosureIndex"):
+			fmt.Fprintf(w, `{"lastDisclosureIndex":"%d"}`, to)
+		case strings.HasSuffix(r.URL.Path, "/blockedDisclosures"):
+			items := make([]string, len(blocked))
+			for i, idx := range blocked {
+				items[i] = strconv.Itoa(idx)
+			}
+			fmt.Fprintf(w, "[%s]", strings.Join(items, ","))
+		case strings.Contains(r.URL.Path, "/disclosureDetail/"):
+			idx, _ := strconv.Atoi(path.Base(r.URL.Path))
+			if slices.Contains(failing, idx) {
+				http.Error(w, "unavailable", http.StatusInternalServerError)
+				return
+			}
+			fmt.Fprintf(w, `{"disclosureIndex":%d}`, idx)
+		default:
+			start, _ := strconv.Atoi(r.URL.Query().Get("disclosureIndex"))
+			var items []string
+			for idx := max(start, from); idx <= to && len(items) < disclosurePageSize; idx++ {
+				items = append(items, fmt.Sprintf(`{"disclosureIndex":"%d"}`, idx))
+			}
+			fmt.Fprintf(w, "[%s]", strings.Join(items, ","))
+		}
+	}
+}
+
+// collect returns a sink that records the indices it receives, checking
+// that each detail matches its disclosure, and fails for the indices in
+// fail.
+func collect(t *testing.T, got *[]int, fail ...int) func(Disclosure, *DisclosureDetail) error {
+	return func(d Disclosure, detail *DisclosureDetail) error {
+		idx := int(d.DisclosureIndex)
+		if int(detail.DisclosureIndex) != idx {
+			t.Errorf("disclosure %d delivered with detail %d", idx, detail.DisclosureIndex)
+		}
+		if slices.Contains(fail, idx) {
+			return fmt.Errorf("sink failed at %d", idx)
+		}
+		*got = append(*got, idx)
+		return nil
+	}
+}
+
+func TestBackfillRun(t *testing.T) {
+	dir := t.TempDir()
+	c := newTestClient(t, backfillServer(1000, 1120, []int{1004, 1060}, []int{1005}), WithBlockList(time.Hour))
+	checkpoint := NewFileCheckpoint(filepath.Join(dir, "checkpoint"))
+	b := c.NewBackfill(&BackfillOptions{
+		Start:       1000,
+		End:         1100,
+		Workers:     3,
+		Checkpoint:  checkpoint,
+		FailureFile: filepath.Join(dir, "failures"),
+	})
+
+	var got []int
+	if err := b.Run(context.Background(), collect(t, &got)); err != nil {
+		t.Fatal(err)
+	}
+	var want []int
+	for idx := 1000; idx <= 1100; idx++ {
+		if idx != 1004 && idx != 1005 && idx != 1060 {
+			want = append(want, idx)
+		}
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("delivered %v, want %v", got, want)
+	}
+	if b.HighWaterMark() != 1100 {
+		t.Errorf("high-water mark = %d, want 1100", b.HighWaterMark())
+	}
+	if saved, err := checkpoint.Load(context.Background()); err != nil || saved != 1100 {
+		t.Errorf("checkpoint = %d, %v; want 1100", saved, err)
+	}
+
+	failures, err := ReadBackfillFailures(filepath.Join(dir, "failures"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var failed []int
+	for _, f := range failures {
+		failed = append(failed, f.Index)
+	}
+	if !slices.Equal(failed, []int{1004, 1060}) || len(b.Failures()) != 2 {
+		t.Errorf("failures = %v, Failures() = %v; want [1004 1060]", failed, b.Failures())
+	}
+}
+
+func TestBackfillResume(t *testing.T) {
+	checkpoint := NewFileCheckpoint(filepath.Join(t.TempDir(), "checkpoint"))
+	c := newTestClient(t, backfillServer(1000, 1020, nil, nil))
+	opts := &BackfillOptions{Start: 1000, End: 1009, Checkpoint: checkpoint}
+
+	// A failing sink stops the run with the checkpoint before it.
+	var got []int
+	err := c.NewBackfill(opts).Run(context.Background(), collect(t, &got, 1006))
+	if err == nil || !strings.Contains(err.Error(), "sink failed at 1006") {
+		t.Fatalf("Run error = %v, want the sink error", err)
+	}
+	if saved, err := checkpoint.Load(context.Background()); err != nil || saved != 1005 {
+		t.Errorf("checkpoint = %d, %v; want 1005", saved, err)
+	}
+
+	// Rerunning with the same Start resumes after the checkpoint.
+	b := c.NewBackfill(opts)
+	if err := b.Run(context.Background(), collect(t, &got)); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1000, 1001, 1002, 1003, 1004, 1005, 1006, 1007, 1008, 1009}; !slices.Equal(got, want) {
+		t.Errorf("delivered %v, want %v", got, want)
+	}
+
+	// A checkpoint before Start does not move the start back.
+	if err := checkpoint.Save(context.Background(), 900); err != nil {
+		t.Fatal(err)
+	}
+	got = nil
+	if err := c.NewBackfill(&BackfillOptions{Start: 1008, End: 1009, Checkpoint: checkpoint}).Run(context.Background(), collect(t, &got)); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, []int{1008, 1009}) {
+		t.Errorf("delivered %v, want [1008 1009]", got)
+	}
+}
+
+func TestBackfillRetryFailures(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "failures")
+	for _, idx := range []int{1007, 1004, 1004, 1005, 1006} {
+		if err := appendLine(file, fmt.Appendf(nil, `{"index":%d,"error":"earlier failure"}`, idx)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 1005 is now blocked and 1006 still fails.
+	c := newTestClient(t, backfillServer(1000, 1010, []int{1006}, []int{1005}), WithBlockList(time.Hour))
+	b := c.NewBackfill(&BackfillOptions{FailureFile: file})
+
+	remaining := func() []int {
+		t.Helper()
+		failures, err := ReadBackfillFailures(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var indices []int
+		for _, f := range failures {
+			indices = append(indices, f.Index)
+		}
+		return indices
+	}
+
+	// A sink error keeps the disclosure it failed on and those after it.
+	var got []int
+	if err := b.RetryFailures(context.Background(), collect(t, &got, 1004)); err == nil {
+		t.Fatal("RetryFailures succeeded despite the sink error")
+	}
+	if len(got) != 0 {
+		t.Errorf("delivered %v, want none", got)
+	}
+	if r := remaining(); !slices.Equal(r, []int{1004, 1006, 1007}) {
+		t.Errorf("remaining %v, want [1004 1006 1007]", r)
+	}
+
+	if err := b.RetryFailures(context.Background(), collect(t, &got)); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, []int{1004, 1007}) {
+		t.Errorf("delivered %v, want [1004 1007]", got)
+	}
+	if r := remaining(); !slices.Equal(r, []int{1006}) {
+		t.Errorf("remaining %v, want [1006]", r)
+	}
+
+	if err := c.NewBackfill(nil).RetryFailures(context.Background(), collect(t, &got)); err == nil {
+		t.Error("RetryFailures without a failure file succeeded")
+	}
+	if _, err := ReadBackfillFailures(filepath.Join(t.TempDir(), "missing")); err != nil {
+		t.Errorf("ReadBackfillFailures of a missing file: %v", err)
+	}
+}
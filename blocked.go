@@ -0,0 +1,396 @@
+package kap
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrBlocked is returned, with WithBlockList, for disclosures and
+// attachments that are on the block list and must not be redistributed.
+var ErrBlocked = errors.New("kap: blocked by KAP")
+
+// defaultBlockedList is the member under which BlockedBase marshals its
+// items when it was not decoded from an object.
+const defaultBlockedList = "blockedDisclosures"
+
+// BlockedBase is the block list returned by BlockedDisclosures.
+//
+// The API does not document its schema, so decoding is tolerant of its
+// shape: the response may be an array of items or an object whose array
+// members hold items, and other members are kept in Extra and written back
+// by MarshalJSON. It is not tolerant of items it cannot read: since
+// blocked content must not be served, an item that cannot be decoded
+// fails the whole list.
+type BlockedBase struct {
+	Items []BlockedItem
+
+	// Extra holds the non-list members of an object response.
+	Extra map[string]json.RawMessage
+}
+
+// BlockedItem is an entry of the block list. An item without attachment
+// IDs blocks the whole disclosure; otherwise only the listed attachments
+// are blocked.
+type BlockedItem struct {
+	DisclosureIndex Index
+	AttachmentIDs   []string
+
+	// Extra holds members that are not recognized.
+	Extra map[string]json.RawMessage
+
+	list string // member of the response the item was listed under
+}
+
+// blockedIndexKeys and blockedAttachmentKeys are the member names, compared
+// case-insensitively, recognized in a BlockedItem.
+var (
+	blockedIndexKeys      = []string{"disclosureIndex", "disclosureId", "index"}
+	blockedAttachmentKeys = []string{"attachmentId", "attachmentIds", "attachmentUrl", "attachmentUrls", "fileId", "fileIds"}
+)
+
+// DisclosureBlocked reports whether the disclosure at index is blocked as
+// a whole.
+func (b *BlockedBase) DisclosureBlocked(index int) bool {
+	for _, it := range b.Items {
+		if int(it.DisclosureIndex) == index && len(it.AttachmentIDs) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// AttachmentBlocked reports whether the attachment with the given ID is
+// blocked.
+func (b *BlockedBase) AttachmentBlocked(id string) bool {
+	for _, it := range b.Items {
+		for _, a := range it.AttachmentIDs {
+			if a == id {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Item returns the entry for the disclosure at index.
+func (b *BlockedBase) Item(index int) (BlockedItem, bool) {
+	for _, it := range b.Items {
+		if int(it.DisclosureIndex) == index {
+			return it, true
+		}
+	}
+	return BlockedItem{}, false
+}
+
+// UnmarshalJSON decodes an array of items or an object holding them.
+func (b *BlockedBase) UnmarshalJSON(data []byte) error {
+	*b = BlockedBase{}
+	data = bytes.TrimSpace(data)
+	switch {
+	case len(data) == 0 || string(data) == "null":
+		return nil
+	case data[0] == '[':
+		items, err := decodeBlockedItems(data, "")
+		b.Items = items
+		return err
+	}
+
+	members, err := objectMembers(data)
+	if err != nil {
+		return err
+	}
+	for _, m := range members {
+		items, err := decodeBlockedItems(m.value, m.name)
+		if err != nil {
+			return err
+		}
+		if len(items) > 0 {
+			b.Items = append(b.Items, items...)
+			continue
+		}
+		if b.Extra == nil {
+			b.Extra = make(map[string]json.RawMessage)
+		}
+		b.Extra[m.name] = m.value
+	}
+	return nil
+}
+
+// MarshalJSON encodes b in the form it was decoded from.
+func (b BlockedBase) MarshalJSON() ([]byte, error) {
+	if len(b.Extra) == 0 && (len(b.Items) == 0 || b.Items[0].list == "") {
+		return json.Marshal(b.Items)
+	}
+	obj := make(map[string]any, len(b.Extra)+1)
+	for k, v := range b.Extra {
+		obj[k] = v
+	}
+	for _, it := range b.Items {
+		list := it.list
+		if list == "" {
+			list = defaultBlockedList
+		}
+		items, _ := obj[list].([]BlockedItem)
+		obj[list] = append(items, it)
+	}
+	return json.Marshal(obj)
+}
+
+// decodeBlockedItems decodes an array of items. It returns nil and no
+// error if data is not an array, and an error if any element is not an
+// item.
+func decodeBlockedItems(data json.RawMessage, list string) ([]BlockedItem, error) {
+	var elems []json.RawMessage
+	if json.Unmarshal(data, &elems) != nil {
+		return nil, nil
+	}
+	items := make([]BlockedItem, 0, len(elems))
+	for i, e := range elems {
+		var it BlockedItem
+		if err := json.Unmarshal(e, &it); err != nil {
+			return nil, fmt.Errorf("kap: invalid block list item %d: %w", i, err)
+		}
+		if it.DisclosureIndex == 0 && len(it.AttachmentIDs) == 0 {
+			return nil, fmt.Errorf("kap: block list item %d names no disclosure or attachment: %s", i, e)
+		}
+		it.list = list
+		items = append(items, it)
+	}
+	return items, nil
+}
+
+// UnmarshalJSON decodes a block list item: an object, or a bare disclosure
+// index. Attachments may be given as IDs, as URLs, or as objects shaped
+// like AttachmentURL; URLs are reduced to their IDs.
+func (it *BlockedItem) UnmarshalJSON(data []byte) error {
+	*it = BlockedItem{}
+	if d := bytes.TrimSpace(data); len(d) > 0 && d[0] != '{' {
+		return json.Unmarshal(d, &it.DisclosureIndex)
+	}
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	for k, v := range obj {
+		switch {
+		case containsFold(blockedIndexKeys, k):
+			if err := json.Unmarshal(v, &it.DisclosureIndex); err != nil {
+				return err
+			}
+		case containsFold(blockedAttachmentKeys, k):
+			ids, err := attachmentRefs(v)
+			if err != nil {
+				return fmt.Errorf("kap: invalid %s: %w", k, err)
+			}
+			for _, id := range ids {
+				if u, err := (AttachmentURL{URL: id}).ID(); err == nil {
+					id = u
+				}
+				it.AttachmentIDs = append(it.AttachmentIDs, path.Base(id))
+			}
+		default:
+			if it.Extra == nil {
+				it.Extra = make(map[string]json.RawMessage)
+			}
+			it.Extra[k] = v
+		}
+	}
+	return nil
+}
+
+// MarshalJSON encodes it as an object with "disclosureIndex" and
+// "attachmentIds" members and its unrecognized members.
+func (it BlockedItem) MarshalJSON() ([]byte, error) {
+	obj := make(map[string]any, len(it.Extra)+2)
+	for k, v := range it.Extra {
+		obj[k] = v
+	}
+	obj["disclosureIndex"] = it.DisclosureIndex
+	if len(it.AttachmentIDs) > 0 {
+		obj["attachmentIds"] = it.AttachmentIDs
+	}
+	return json.Marshal(obj)
+}
+
+// attachmentRefs decodes an attachment reference or an array of them as
+// strings. A reference is a scalar ID or URL, or an object decoded as an
+// AttachmentURL, which must have a URL.
+func attachmentRefs(data json.RawMessage) ([]string, error) {
+	var elems []json.RawMessage
+	if err := json.Unmarshal(data, &elems); err != nil {
+		elems = []json.RawMessage{data}
+	}
+	var list []string
+	for _, e := range elems {
+		if e := bytes.TrimSpace(e); len(e) > 0 && e[0] == '{' {
+			var a AttachmentURL
+			if err := json.Unmarshal(e, &a); err != nil {
+				return nil, err
+			}
+			if strings.TrimSpace(a.URL) == "" {
+				return nil, fmt.Errorf("kap: attachment without a URL: %s", e)
+			}
+			list = append(list, strings.TrimSpace(a.URL))
+			continue
+		}
+		s, ok, err := flexText(e)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			list = append(list, s)
+		}
+	}
+	return list, nil
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// blockList caches the block list for WithBlockList.
+type blockList struct {
+	refresh time.Duration
+
+	mu          sync.Mutex // protects the fields below
+	fetched     time.Time
+	disclosures map[int]bool
+	attachments map[string]bool
+	loading     *blockListLoad
+}
+
+// blockListLoad tracks a single in-flight block list fetch shared by all
+// callers that find the cache stale at the same time.
+type blockListLoad struct {
+	done        chan struct{}
+	disclosures map[int]bool
+	attachments map[string]bool
+	err         error
+}
+
+// load returns the blocked disclosures and attachments, refreshing the
+// cache first if it is older than the refresh interval. Concurrent callers
+// share a single fetch, made without holding the lock; like a token
+// refresh it is not cancelled when ctx is. If the refresh fails the error
+// is returned, so that nothing is served unchecked. The returned maps are
+// never modified.
+func (b *blockList) load(ctx context.Context, c *Client) (map[int]bool, map[string]bool, error) {
+	b.mu.Lock()
+	if !b.fetched.IsZero() && time.Since(b.fetched) < b.refresh {
+		defer b.mu.Unlock()
+		return b.disclosures, b.attachments, nil
+	}
+	l := b.loading
+	if l == nil {
+		l = &blockListLoad{done: make(chan struct{})}
+		b.loading = l
+		go b.fetch(context.WithoutCancel(ctx), c, l)
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-l.done:
+		return l.disclosures, l.attachments, l.err
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
+
+// fetch fetches the block list for l and publishes the result.
+func (b *blockList) fetch(ctx context.Context, c *Client, l *blockListLoad) {
+	list, err := c.BlockedDisclosures(ctx)
+	if err != nil {
+		l.err = fmt.Errorf("kap: refreshing block list: %w", err)
+	} else {
+		l.disclosures = make(map[int]bool)
+		l.attachments = make(map[string]bool)
+		for _, it := range list.Items {
+			if len(it.AttachmentIDs) == 0 {
+				l.disclosures[int(it.DisclosureIndex)] = true
+			}
+			for _, id := range it.AttachmentIDs {
+				l.attachments[id] = true
+			}
+		}
+	}
+
+	b.mu.Lock()
+	if l.err == nil {
+		b.disclosures, b.attachments = l.disclosures, l.attachments
+		b.fetched = time.Now()
+	}
+	b.loading = nil
+	b.mu.Unlock()
+
+	close(l.done)
+}
+
+// checkDisclosure returns ErrBlocked if the disclosure at index is
+// blocked.
+func (c *Client) checkDisclosure(ctx context.Context, index int) error {
+	if c.blockList == nil {
+		return nil
+	}
+	disclosures, _, err := c.blockList.load(ctx, c)
+	if err != nil {
+		return err
+	}
+	if disclosures[index] {
+		return fmt.Errorf("%w: disclosure %d", ErrBlocked, index)
+	}
+	return nil
+}
+
+// checkAttachment returns ErrBlocked if the attachment with the given ID
+// is blocked.
+func (c *Client) checkAttachment(ctx context.Context, id string) error {
+	if c.blockList == nil {
+		return nil
+	}
+	_, attachments, err := c.blockList.load(ctx, c)
+	if err != nil {
+		return err
+	}
+	if attachments[id] {
+		return fmt.Errorf("%w: attachment %s", ErrBlocked, id)
+	}
+	return nil
+}
+
+// removeBlockedAttachments drops blocked attachments from d.
+func (c *Client) removeBlockedAttachments(ctx context.Context, d *DisclosureDetail) error {
+	if c.blockList == nil {
+		return nil
+	}
+	var kept []AttachmentURL
+	for _, a := range d.AttachmentURLs {
+		id, err := a.ID()
+		if err != nil {
+			// Not downloadable through the API, so nothing to refuse.
+			kept = append(kept, a)
+			continue
+		}
+		err = c.checkAttachment(ctx, id)
+		if errors.Is(err, ErrBlocked) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		kept = append(kept, a)
+	}
+	d.AttachmentURLs = kept
+	return nil
+}
@@ -0,0 +1,166 @@
+package kap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBlockedBaseUnmarshal(t *testing.T) {
+	tests := []struct {
+		name        string
+		in          string
+		disclosures []int
+		attachments []string
+	}{
+		{
+			name:        "object with attachment objects",
+			in:          `{"blockedDisclosures":[{"disclosureIndex":10},{"disclosureIndex":11,"attachmentUrls":[{"url":"https://www.kap.org.tr/api/file/downloadAttachment/abc","fileName":"a.pdf"}]}]}`,
+			disclosures: []int{10},
+			attachments: []string{"abc"},
+		},
+		{
+			name:        "attachment URL strings",
+			in:          `[{"disclosureIndex":"12","attachmentUrls":["https://host/api/vyk/downloadAttachment/def"]}]`,
+			attachments: []string{"def"},
+		},
+		{
+			name:        "attachment IDs",
+			in:          `{"items":[{"disclosureId":13,"attachmentId":"ghi"}],"count":1}`,
+			attachments: []string{"ghi"},
+		},
+		{
+			name:        "bare indices",
+			in:          `[14, "15"]`,
+			disclosures: []int{14, 15},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b BlockedBase
+			if err := json.Unmarshal([]byte(tt.in), &b); err != nil {
+				t.Fatal(err)
+			}
+			var disclosures []int
+			var attachments []string
+			for _, it := range b.Items {
+				if len(it.AttachmentIDs) == 0 {
+					disclosures = append(disclosures, int(it.DisclosureIndex))
+				}
+				attachments = append(attachments, it.AttachmentIDs...)
+			}
+			if !slices.Equal(disclosures, tt.disclosures) || !slices.Equal(attachments, tt.attachments) {
+				t.Errorf("blocked disclosures %v, attachments %v; want %v, %v", disclosures, attachments, tt.disclosures, tt.attachments)
+			}
+			for _, idx := range tt.disclosures {
+				if !b.DisclosureBlocked(idx) {
+					t.Errorf("DisclosureBlocked(%d) = false", idx)
+				}
+			}
+			for _, id := range tt.attachments {
+				if !b.AttachmentBlocked(id) {
+					t.Errorf("AttachmentBlocked(%s) = false", id)
+				}
+			}
+		})
+	}
+}
+
+func TestBlockedBaseUnmarshalFailsClosed(t *testing.T) {
+	for _, in := range []string{
+		`{"blockedDisclosures":[{"disclosureIndex":10},{"disclosureIndex":11,"attachmentUrls":[{"fileName":"a.pdf"}]}]}`,
+		`{"blockedDisclosures":[{"disclosureIndex":10},{"disclosureIndex":{"value":11}}]}`,
+		`{"blockedDisclosures":[{"disclosureIndex":10},{"reason":"court order"}]}`,
+		`[10, "eleven"]`,
+	} {
+		var b BlockedBase
+		if err := json.Unmarshal([]byte(in), &b); err == nil {
+			t.Errorf("Unmarshal(%s) = %d items, want error", in, len(b.Items))
+		}
+	}
+}
+
+func TestBlockListFailsClosed(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/blockedDisclosures") {
+			w.Write([]byte(`{"blockedDisclosures":[{"disclosureIndex":10},{"disclosureIndex":11,"attachmentUrls":[{"name":"x"}]}]}`)) //nolint:errcheck // test server
+			return
+		}
+		w.Write([]byte(`{}`)) //nolint:errcheck // test server
+	}, WithBlockList(time.Hour))
+
+	if _, err := c.BlockedDisclosures(context.Background()); err == nil {
+		t.Error("BlockedDisclosures succeeded")
+	}
+	for _, idx := range []int{10, 12} {
+		_, err := c.DisclosureDetail(context.Background(), idx, "data", nil)
+		if err == nil || errors.Is(err, ErrBlocked) {
+			t.Errorf("DisclosureDetail(%d) error = %v, want a block list error", idx, err)
+		}
+	}
+	if _, _, err := c.DownloadAttachment(context.Background(), "abc"); err == nil {
+		t.Error("DownloadAttachment succeeded")
+	}
+}
+
+func TestBlockListSharedFetch(t *testing.T) {
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/blockedDisclosures") {
+			fetches.Add(1)
+			<-release
+			w.Write([]byte(`[{"disclosureIndex":10},{"disclosureIndex":11,"attachmentUrls":[{"url":"https://host/downloadAttachment/abc"}]}]`)) //nolint:errcheck // test server
+			return
+		}
+		w.Write([]byte(`{"attachmentUrls":[{"url":"https://host/downloadAttachment/abc"},{"url":"https://host/downloadAttachment/def"}]}`)) //nolint:errcheck // test server
+	}, WithBlockList(time.Hour))
+
+	// A caller whose context ends while the list is being fetched is not
+	// held up by the fetch.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.DisclosureDetail(ctx, 12, "data", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("DisclosureDetail with expiring context error = %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	details := make([]*DisclosureDetail, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			details[i], errs[i] = c.DisclosureDetail(context.Background(), 10+i%3, "data", nil)
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if n := fetches.Load(); n != 1 {
+		t.Errorf("block list fetched %d times, want 1", n)
+	}
+	for i, err := range errs {
+		switch idx := 10 + i%3; idx {
+		case 10:
+			if !errors.Is(err, ErrBlocked) {
+				t.Errorf("DisclosureDetail(10) error = %v, want ErrBlocked", err)
+			}
+		default:
+			if err != nil {
+				t.Fatalf("DisclosureDetail(%d): %v", idx, err)
+			}
+			if got := len(details[i].AttachmentURLs); got != 1 {
+				t.Errorf("DisclosureDetail(%d) has %d attachments, want 1", idx, got)
+			}
+		}
+	}
+}
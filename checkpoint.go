@@ -0,0 +1,58 @@
+package kap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Checkpoint persists a disclosure index, such as the high-water mark of a
+// Watcher, so that long-running jobs can resume after a restart.
+type Checkpoint interface {
+	// Load returns the saved index, or zero if none has been saved.
+	Load(ctx context.Context) (int, error)
+
+	// Save stores index, replacing any previous value.
+	Save(ctx context.Context, index int) error
+}
+
+// FileCheckpoint is a Checkpoint stored as a decimal number in a text
+// file. Saves replace the file atomically.
+type FileCheckpoint struct {
+	path string
+}
+
+// NewFileCheckpoint creates a checkpoint stored in the file at path. The
+// file and its directory are created on first Save.
+func NewFileCheckpoint(path string) *FileCheckpoint {
+	return &FileCheckpoint{path: path}
+}
+
+// Load reads the checkpoint file. A missing file yields zero.
+func (c *FileCheckpoint) Load(_ context.Context) (int, error) {
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("kap: reading checkpoint: %w", err)
+	}
+
+	index, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("kap: decoding checkpoint: %w", err)
+	}
+	return index, nil
+}
+
+// Save writes index to the checkpoint file.
+func (c *FileCheckpoint) Save(_ context.Context, index int) error {
+	if err := writeFileAtomic(c.path, []byte(strconv.Itoa(index)+"\n"), 0o644); err != nil {
+		return fmt.Errorf("kap: writing checkpoint: %w", err)
+	}
+	return nil
+}
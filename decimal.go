@@ -0,0 +1,307 @@
+package kap
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxDecimalExponent bounds the exponent and scale of a Decimal, so that
+// input such as "1e9999999" cannot make the client build a number with
+// millions of digits.
+const maxDecimalExponent = 400
+
+// ErrAmbiguousDecimal is returned by ParseDecimal for text such as "5.000"
+// or "1.250", in which a single "." may be a Turkish thousands separator
+// or a decimal point. Decoding JSON never returns it.
+var ErrAmbiguousDecimal = errors.New("kap: ambiguous decimal separator")
+
+// Decimal is an exact decimal number, such as a share capital. It is sent
+// by the API either as a JSON number or as a string, possibly in Turkish
+// notation ("1.250.000,50"). Decimal keeps every digit, including trailing
+// zeros, and marshals back to exactly the JSON it was decoded from.
+//
+// The zero Decimal is 0. Decimals are immutable; use Cmp or Equal rather
+// than == to compare values, since == also compares the original text.
+type Decimal struct {
+	text string // canonical form: optional "-", digits, optional "." and digits
+	raw  string // JSON the value was decoded from, if any
+}
+
+// ParseDecimal parses s as a decimal number. It accepts Go and JSON
+// syntax, including an exponent, and Turkish notation with "." as the
+// thousands separator and "," as the decimal separator. Exponents and
+// scales beyond ±400 are rejected.
+//
+// A single "." followed by exactly three digits, as in "5.000", reads as
+// 5 in Go syntax and as 5000 in Turkish notation, so ParseDecimal returns
+// an error wrapping ErrAmbiguousDecimal rather than guess. Write such
+// values as "5000", "5.000,00" or "5.0" to make the notation clear.
+func ParseDecimal(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	n, err := normalizeNumber(s)
+	if err != nil {
+		return Decimal{}, err
+	}
+	return parseDecimalText(s, n)
+}
+
+// parseDecimalText parses n, in Go syntax, reporting errors against the
+// original text s.
+func parseDecimalText(s, n string) (Decimal, error) {
+	unscaled, scale, err := parseDecimal(n)
+	if err != nil {
+		return Decimal{}, fmt.Errorf("kap: invalid decimal %q", s)
+	}
+	return newDecimal(unscaled, scale), nil
+}
+
+// NewDecimal returns the decimal unscaled × 10^-scale. For example,
+// NewDecimal(12550, 2) is 125.50. It panics if scale is beyond ±400.
+func NewDecimal(unscaled int64, scale int) Decimal {
+	if scale < -maxDecimalExponent || scale > maxDecimalExponent {
+		panic("kap: Decimal scale out of range: " + strconv.Itoa(scale))
+	}
+	return newDecimal(big.NewInt(unscaled), scale)
+}
+
+func newDecimal(unscaled *big.Int, scale int) Decimal {
+	if scale < 0 {
+		unscaled = new(big.Int).Mul(unscaled, pow10(-scale))
+		scale = 0
+	}
+	return Decimal{text: formatDecimal(unscaled, scale)}
+}
+
+// String returns d in canonical form, such as "-1250000.50", keeping the
+// scale it was parsed with.
+func (d Decimal) String() string {
+	if d.text == "" {
+		return "0"
+	}
+	return d.text
+}
+
+// Scale returns the number of digits after the decimal point.
+func (d Decimal) Scale() int {
+	if i := strings.IndexByte(d.text, '.'); i >= 0 {
+		return len(d.text) - i - 1
+	}
+	return 0
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of d.
+func (d Decimal) Sign() int {
+	unscaled, _ := d.big()
+	return unscaled.Sign()
+}
+
+// IsZero reports whether d is zero.
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Cmp compares d and y and returns -1, 0 or +1. Scale is ignored, so 1.5
+// and 1.50 compare equal.
+func (d Decimal) Cmp(y Decimal) int {
+	a, b, _ := align(d, y)
+	return a.Cmp(b)
+}
+
+// Equal reports whether d and y have the same value.
+func (d Decimal) Equal(y Decimal) bool {
+	return d.Cmp(y) == 0
+}
+
+// Add returns d + y.
+func (d Decimal) Add(y Decimal) Decimal {
+	a, b, scale := align(d, y)
+	return newDecimal(a.Add(a, b), scale)
+}
+
+// Sub returns d - y.
+func (d Decimal) Sub(y Decimal) Decimal {
+	a, b, scale := align(d, y)
+	return newDecimal(a.Sub(a, b), scale)
+}
+
+// Mul returns d × y.
+func (d Decimal) Mul(y Decimal) Decimal {
+	a, as := d.big()
+	b, bs := y.big()
+	return newDecimal(a.Mul(a, b), as+bs)
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	unscaled, scale := d.big()
+	return newDecimal(unscaled.Neg(unscaled), scale)
+}
+
+// Abs returns |d|.
+func (d Decimal) Abs() Decimal {
+	unscaled, scale := d.big()
+	return newDecimal(unscaled.Abs(unscaled), scale)
+}
+
+// Rat returns d as an exact rational number.
+func (d Decimal) Rat() *big.Rat {
+	unscaled, scale := d.big()
+	return new(big.Rat).SetFrac(unscaled, pow10(scale))
+}
+
+// Float64 returns the float64 nearest to d.
+func (d Decimal) Float64() float64 {
+	f, _ := d.Rat().Float64()
+	return f
+}
+
+// MarshalJSON returns the JSON d was decoded from, or d as a JSON number
+// if it was not decoded.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	if d.raw != "" {
+		return []byte(d.raw), nil
+	}
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON decodes a number, a numeric string, "" or null. JSON
+// numbers always use "." as the decimal point. Strings are read in the
+// Turkish notation the API documents for amounts such as share capitals,
+// so a single "." followed by three digits, as in "150.000", is a
+// thousands separator; other strings are parsed as by ParseDecimal. The
+// text is kept as sent, so MarshalJSON writes it back unchanged.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s, ok, err := flexText(data)
+	if err != nil {
+		return err
+	}
+	var v Decimal
+	switch trimmed := bytes.TrimSpace(data); {
+	case !ok:
+	case trimmed[0] != '"':
+		v, err = parseDecimalText(s, s)
+	default:
+		v, err = parseDecimalText(s, turkishNumber(s))
+	}
+	if err != nil {
+		return err
+	}
+	v.raw = string(bytes.TrimSpace(data))
+	*d = v
+	return nil
+}
+
+// big returns the unscaled value and scale of d.
+func (d Decimal) big() (*big.Int, int) {
+	scale := d.Scale()
+	unscaled, ok := new(big.Int).SetString(strings.Replace(d.String(), ".", "", 1), 10)
+	if !ok {
+		panic("kap: malformed Decimal " + strconv.Quote(d.text))
+	}
+	return unscaled, scale
+}
+
+// align returns the unscaled values of x and y at their common scale.
+func align(x, y Decimal) (a, b *big.Int, scale int) {
+	a, as := x.big()
+	b, bs := y.big()
+	scale = max(as, bs)
+	a.Mul(a, pow10(scale-as))
+	b.Mul(b, pow10(scale-bs))
+	return a, b, scale
+}
+
+// parseDecimal parses a number in Go syntax into its unscaled value and
+// scale. The scale may be negative for a positive exponent.
+func parseDecimal(s string) (*big.Int, int, error) {
+	mantissa, exp := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			return nil, 0, err
+		}
+		mantissa, exp = s[:i], e
+	}
+
+	sign := ""
+	if mantissa != "" && (mantissa[0] == '-' || mantissa[0] == '+') {
+		sign, mantissa = mantissa[:1], mantissa[1:]
+	}
+	intPart, frac, _ := strings.Cut(mantissa, ".")
+	digits := intPart + frac
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return nil, 0, strconv.ErrSyntax
+	}
+
+	scale := len(frac) - exp
+	if exp < -maxDecimalExponent || exp > maxDecimalExponent ||
+		scale < -maxDecimalExponent || scale > maxDecimalExponent {
+		return nil, 0, strconv.ErrRange
+	}
+
+	unscaled, ok := new(big.Int).SetString(sign+digits, 10)
+	if !ok {
+		return nil, 0, strconv.ErrSyntax
+	}
+	return unscaled, scale, nil
+}
+
+// formatDecimal formats unscaled × 10^-scale with exactly scale fraction
+// digits.
+func formatDecimal(unscaled *big.Int, scale int) string {
+	digits := new(big.Int).Abs(unscaled).String()
+	if scale > 0 {
+		if len(digits) <= scale {
+			digits = strings.Repeat("0", scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+	}
+	if unscaled.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// normalizeNumber converts a number in Turkish notation, with "." as the
+// thousands separator and "," as the decimal separator, to Go syntax.
+// Other input is returned unchanged. It returns ErrAmbiguousDecimal for a
+// single "." that may be either separator.
+func normalizeNumber(s string) (string, error) {
+	if ambiguousSeparator(s) {
+		return "", fmt.Errorf("%w: %q", ErrAmbiguousDecimal, s)
+	}
+	return turkishNumber(s), nil
+}
+
+// turkishNumber is normalizeNumber without the ambiguity check: a single
+// "." that may be either separator is read as a thousands separator.
+func turkishNumber(s string) string {
+	switch {
+	case strings.Contains(s, ","):
+		return strings.ReplaceAll(strings.ReplaceAll(s, ".", ""), ",", ".")
+	case strings.Count(s, ".") > 1 || ambiguousSeparator(s):
+		return strings.ReplaceAll(s, ".", "")
+	}
+	return s
+}
+
+// ambiguousSeparator reports whether s is 1 to 3 digits, not starting
+// with 0, then "." and exactly three digits, optionally signed.
+func ambiguousSeparator(s string) bool {
+	s = strings.TrimLeft(s, "+-")
+	intPart, frac, ok := strings.Cut(s, ".")
+	return ok && len(intPart) >= 1 && len(intPart) <= 3 && intPart[0] != '0' &&
+		len(frac) == 3 && allDigits(intPart) && allDigits(frac)
+}
+
+func allDigits(s string) bool {
+	return strings.Trim(s, "0123456789") == ""
+}
@@ -0,0 +1,192 @@
+package kap
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"0", "0"},
+		{"125.50", "125.50"},
+		{"-0.001", "-0.001"},
+		{"+7", "7"},
+		{"1.5e3", "1500"},
+		{"15E-1", "1.5"},
+		{"1e400", "1" + strings.Repeat("0", 400)},
+		{"1e-400", "0." + strings.Repeat("0", 399) + "1"},
+		{"1.250.000,50", "1250000.50"},
+		{"1.250.000", "1250000"},
+		{"3,75", "3.75"},
+		{" 42 ", "42"},
+	}
+	for _, tt := range tests {
+		d, err := ParseDecimal(tt.in)
+		if err != nil {
+			t.Errorf("ParseDecimal(%q): %v", tt.in, err)
+			continue
+		}
+		if got := d.String(); got != tt.want {
+			t.Errorf("ParseDecimal(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseDecimalInvalid(t *testing.T) {
+	for _, in := range []string{
+		"", "abc", "1.2.3e", "1e", "--1", "1,2,3",
+		"1e401", "1e-401", "1e9999999", "-1e-9999999", "1e99999999999999999999",
+		"0." + strings.Repeat("0", 401) + "1",
+	} {
+		if d, err := ParseDecimal(in); err == nil {
+			t.Errorf("ParseDecimal(%q) = %s, want error", in, d)
+		}
+	}
+}
+
+func TestParseDecimalAmbiguous(t *testing.T) {
+	tests := []struct {
+		in        string
+		ambiguous bool
+	}{
+		{"5.000", true},
+		{"1.250", true},
+		{"-999.999", true},
+		{"0.125", false},
+		{"1234.567", false},
+		{"1.25", false},
+		{"1.2500", false},
+		{"1.250,00", false},
+		{"1.250.000", false},
+		{"1.250e3", false},
+	}
+	for _, tt := range tests {
+		_, err := ParseDecimal(tt.in)
+		if got := errors.Is(err, ErrAmbiguousDecimal); got != tt.ambiguous {
+			t.Errorf("ParseDecimal(%q) error = %v, want ambiguous %v", tt.in, err, tt.ambiguous)
+		}
+	}
+
+	// A JSON number is never in Turkish notation.
+	var d Decimal
+	if err := json.Unmarshal([]byte(`5.000`), &d); err != nil || d.String() != "5.000" {
+		t.Errorf("Unmarshal(5.000) = %s, %v; want 5.000", d, err)
+	}
+	// A JSON string is read in Turkish notation.
+	if err := json.Unmarshal([]byte(`"5.000"`), &d); err != nil || d.String() != "5000" {
+		t.Errorf("Unmarshal(\"5.000\") = %s, %v; want 5000", d, err)
+	}
+}
+
+// TestDecimalUnmarshalCapital checks that an ambiguous capital does not
+// fail the response it is part of.
+func TestDecimalUnmarshalCapital(t *testing.T) {
+	in := `{"member":{"id":"926","kayitliSermayeTavani":"1.250"},"securities":[{"isin":"TRAAAAA91A1","capital":"150.000","currentCapital":"150.000,00"}]}`
+	var ms MemberSecurities
+	if err := json.Unmarshal([]byte(in), &ms); err != nil {
+		t.Fatal(err)
+	}
+	sec := ms.Securities[0]
+	if sec.Capital.String() != "150000" || sec.CurrentCapital.String() != "150000.00" || ms.Member.KayitliSermayeTavani.String() != "1250" {
+		t.Errorf("capital = %s, current = %s, ceiling = %s", sec.Capital, sec.CurrentCapital, ms.Member.KayitliSermayeTavani)
+	}
+	out, err := json.Marshal(sec.Capital)
+	if err != nil || string(out) != `"150.000"` {
+		t.Errorf("Marshal(capital) = %s, %v; want \"150.000\"", out, err)
+	}
+}
+
+// TestDecimalHugeExponent checks that out-of-range exponents are rejected
+// without first building the number they describe.
+func TestDecimalHugeExponent(t *testing.T) {
+	for _, in := range []string{`1e9999999`, `"1e9999999"`, `1e-9999999`, `1e999999`} {
+		start := time.Now()
+		var d Decimal
+		if err := json.Unmarshal([]byte(in), &d); err == nil {
+			t.Errorf("Unmarshal(%s) succeeded, want error", in)
+		}
+		if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+			t.Errorf("Unmarshal(%s) took %v", in, elapsed)
+		}
+	}
+}
+
+func TestNewDecimalScaleRange(t *testing.T) {
+	if got := NewDecimal(5, -400).Scale(); got != 0 {
+		t.Errorf("NewDecimal(5, -400).Scale() = %d, want 0", got)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("NewDecimal(1, -401) did not panic")
+		}
+	}()
+	NewDecimal(1, -401)
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	a := mustDecimal(t, "1.25")
+	b := mustDecimal(t, "0.750")
+	tests := []struct {
+		name string
+		got  Decimal
+		want string
+	}{
+		{"Add", a.Add(b), "2.000"},
+		{"Sub", a.Sub(b), "0.500"},
+		{"Mul", a.Mul(b), "0.93750"},
+		{"Neg", a.Neg(), "-1.25"},
+		{"Abs", a.Neg().Abs(), "1.25"},
+	}
+	for _, tt := range tests {
+		if got := tt.got.String(); got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+	if !mustDecimal(t, "1.5").Equal(mustDecimal(t, "1.50")) {
+		t.Error("1.5 != 1.50")
+	}
+	if got := a.Cmp(b); got != 1 {
+		t.Errorf("Cmp = %d, want 1", got)
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`"1.250.000,50"`, "1250000.50"},
+		{`1250000.50`, "1250000.50"},
+		{`""`, "0"},
+		{`null`, "0"},
+	}
+	for _, tt := range tests {
+		var d Decimal
+		if err := json.Unmarshal([]byte(tt.in), &d); err != nil {
+			t.Errorf("Unmarshal(%s): %v", tt.in, err)
+			continue
+		}
+		if got := d.String(); got != tt.want {
+			t.Errorf("Unmarshal(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+		out, err := json.Marshal(d)
+		if err != nil || string(out) != tt.in {
+			t.Errorf("Marshal(Unmarshal(%s)) = %s, %v", tt.in, out, err)
+		}
+	}
+}
+
+func mustDecimal(t *testing.T, s string) Decimal {
+	t.Helper()
+	d, err := ParseDecimal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
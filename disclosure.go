@@ -2,71 +2,288 @@ package kap
 
 import (
 	"context"
-	"encoding/json"
 	"net/url"
+	"slices"
 	"strconv"
 )
 
+// disclosurePageSize is the maximum number of disclosures returned by one
+// Disclosures request.
+const disclosurePageSize = 50
+
+// DefaultDetailWorkers is the number of details DisclosureDetails fetches
+// at once when DetailsOptions.Workers is zero.
+const DefaultDetailWorkers = 4
+
 // Disclosures returns up to 50 disclosures starting from the given index.
 // Optional filters can be provided via params.
+//
+// The endpoint is documented to take a single class, type and company per
+// request, so when several are given one request is made for each
+// combination, concurrently, and the results are merged in index order
+// without duplicates. Three classes, two types and two companies thus take
+// twelve requests. A merged page may hold more than 50 disclosures; it
+// always ends before the first disclosure any of the underlying pages could
+// have missed, so paging on from the highest index returned skips nothing.
 func (c *Client) Disclosures(ctx context.Context, disclosureIndex int, params *DisclosureListParams) ([]Disclosure, error) {
-	q := url.Values{}
-	q.Set("disclosureIndex", strconv.Itoa(disclosureIndex))
+	queries := params.queries()
+	for _, q := range queries {
+		q.Set("disclosureIndex", strconv.Itoa(disclosureIndex))
+	}
+	if len(queries) == 1 {
+		return c.disclosurePage(ctx, queries[0])
+	}
 
-	if params != nil {
-		if params.DisclosureClass != "" {
-			q.Set("disclosureClass", params.DisclosureClass)
-		}
-		if params.DisclosureType != "" {
-			q.Set("disclosureTypes", params.DisclosureType)
-		}
-		if params.CompanyID != "" {
-			q.Set("companyId", params.CompanyID)
+	pages := make([][]Disclosure, len(queries))
+	errs := forEach(ctx, len(queries), 0, func(i int) error {
+		var err error
+		pages[i], err = c.disclosurePage(ctx, queries[i])
+		return err
+	})
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
 		}
 	}
+	return mergeDisclosurePages(pages), nil
+}
 
+// disclosurePage performs a single Disclosures request.
+func (c *Client) disclosurePage(ctx context.Context, q url.Values) ([]Disclosure, error) {
 	var disclosures []Disclosure
-	if err := c.get(ctx, "/api/vyk/disclosures", q, &disclosures); err != nil {
+	if err := c.get(ctx, "Disclosures", "/api/vyk/disclosures", q, &disclosures); err != nil {
 		return nil, err
 	}
 	return disclosures, nil
 }
 
+// mergeDisclosurePages merges pages fetched with different filters from
+// the same start index. Each full page may have omitted disclosures above
+// its highest index, so the merged result is cut off at the lowest such
+// index.
+func mergeDisclosurePages(pages [][]Disclosure) []Disclosure {
+	cutoff := -1
+	byIndex := make(map[int]Disclosure)
+	for _, page := range pages {
+		last := -1
+		for _, d := range page {
+			idx := int(d.DisclosureIndex)
+			byIndex[idx] = d
+			last = max(last, idx)
+		}
+		if len(page) >= disclosurePageSize && (cutoff < 0 || last < cutoff) {
+			cutoff = last
+		}
+	}
+
+	indices := make([]int, 0, len(byIndex))
+	for idx := range byIndex {
+		if cutoff < 0 || idx <= cutoff {
+			indices = append(indices, idx)
+		}
+	}
+	slices.Sort(indices)
+
+	merged := make([]Disclosure, len(indices))
+	for i, idx := range indices {
+		merged[i] = byIndex[idx]
+	}
+	return merged
+}
+
+// queries returns the query parameters for each request needed to apply
+// the filters in p, without the disclosure index.
+func (p *DisclosureListParams) queries() []url.Values {
+	if p == nil {
+		return []url.Values{{}}
+	}
+
+	classes := appendUnique(nil, p.DisclosureClass)
+	for _, c := range p.DisclosureClasses {
+		classes = appendUnique(classes, c)
+	}
+	types := appendUnique(nil, p.DisclosureType)
+	for _, t := range p.DisclosureTypes {
+		types = appendUnique(types, t)
+	}
+	companies := appendUnique(nil, p.CompanyID)
+	for _, id := range p.CompanyIDs {
+		companies = appendUnique(companies, id)
+	}
+
+	if len(classes) == 0 {
+		classes = []DisclosureClass{""}
+	}
+	if len(types) == 0 {
+		types = []DisclosureType{""}
+	}
+	if len(companies) == 0 {
+		companies = []ID{""}
+	}
+
+	queries := make([]url.Values, 0, len(classes)*len(types)*len(companies))
+	for _, class := range classes {
+		for _, typ := range types {
+			for _, company := range companies {
+				q := url.Values{}
+				if class != "" {
+					q.Set("disclosureClass", string(class))
+				}
+				if typ != "" {
+					q.Set("disclosureTypes", string(typ))
+				}
+				if company != "" {
+					q.Set("companyId", string(company))
+				}
+				queries = append(queries, q)
+			}
+		}
+	}
+	return queries
+}
+
+// appendUnique appends v to s unless it is empty or already present.
+func appendUnique[T comparable](s []T, v T) []T {
+	var zero T
+	if v == zero || slices.Contains(s, v) {
+		return s
+	}
+	return append(s, v)
+}
+
 // DisclosureDetail returns full details for a disclosure at the given index.
-// fileType must be "html" or "data". subReportList is optional; when empty,
-// all sub-reports are returned.
-func (c *Client) DisclosureDetail(ctx context.Context, disclosureIndex int, fileType string, subReportList string) (*DisclosureDetail, error) {
+// fileType must be "html" or "data". subReports optionally limits the
+// detail to the given sub-reports; when empty, all sub-reports are
+// returned. With WithBlockList, blocked disclosures are refused with
+// ErrBlocked and blocked attachments are omitted.
+//
+// The API takes one sub-report ID in its subReportList parameter. When
+// several are given, each is fetched with its own request and their
+// presentation, flat data and messages are merged into one detail.
+func (c *Client) DisclosureDetail(ctx context.Context, disclosureIndex int, fileType string, subReports []SubReportID) (*DisclosureDetail, error) {
+	ids, err := validSubReports(subReports)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return c.disclosureDetail(ctx, disclosureIndex, fileType, "")
+	}
+
+	detail, err := c.disclosureDetail(ctx, disclosureIndex, fileType, ids[0])
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids[1:] {
+		other, err := c.disclosureDetail(ctx, disclosureIndex, fileType, id)
+		if err != nil {
+			return nil, err
+		}
+		mergeSubReport(detail, other)
+	}
+	return detail, nil
+}
+
+// disclosureDetail fetches a disclosure detail, limited to one sub-report
+// unless subReport is empty.
+func (c *Client) disclosureDetail(ctx context.Context, disclosureIndex int, fileType string, subReport SubReportID) (*DisclosureDetail, error) {
+	if err := c.checkDisclosure(ctx, disclosureIndex); err != nil {
+		return nil, err
+	}
+
 	q := url.Values{}
 	q.Set("fileType", fileType)
-	if subReportList != "" {
-		q.Set("subReportList", subReportList)
+	if subReport != "" {
+		q.Set("subReportList", string(subReport))
 	}
 
 	path := "/api/vyk/disclosureDetail/" + strconv.Itoa(disclosureIndex)
 
 	var detail DisclosureDetail
-	if err := c.get(ctx, path, q, &detail); err != nil {
+	if err := c.get(ctx, "DisclosureDetail", path, q, &detail); err != nil {
+		return nil, err
+	}
+	if err := c.removeBlockedAttachments(ctx, &detail); err != nil {
 		return nil, err
 	}
 	return &detail, nil
 }
 
+// DetailsOptions configures DisclosureDetails. The zero value is usable.
+type DetailsOptions struct {
+	// Workers is the number of details fetched at once. Zero means
+	// DefaultDetailWorkers. Requests still pass through the client's rate
+	// limits.
+	Workers int
+}
+
+// DetailResult is the outcome of fetching one disclosure detail.
+type DetailResult struct {
+	Index  int
+	Detail *DisclosureDetail
+	Err    error
+}
+
+// DisclosureDetails fetches the details of several disclosures
+// concurrently, as DisclosureDetail does. The results are in the order of
+// indices, one per index; an index given more than once is fetched once
+// and its result repeated. A failed fetch sets Err of its result and does
+// not affect the others. opts may be nil.
+func (c *Client) DisclosureDetails(ctx context.Context, indices []int, fileType string, opts *DetailsOptions) []DetailResult {
+	workers := DefaultDetailWorkers
+	if opts != nil && opts.Workers > 0 {
+		workers = opts.Workers
+	}
+
+	results := make([]DetailResult, len(indices))
+	first := make(map[int]int, len(indices)) // index -> position of its first occurrence
+	unique := make([]int, 0, len(indices))   // positions of first occurrences
+	for i, idx := range indices {
+		results[i].Index = idx
+		if _, dup := first[idx]; !dup {
+			first[idx] = i
+			unique = append(unique, i)
+		}
+	}
+	errs := forEach(ctx, len(unique), workers, func(k int) error {
+		r := &results[unique[k]]
+		var err error
+		r.Detail, err = c.DisclosureDetail(ctx, r.Index, fileType, nil)
+		return err
+	})
+	for k, i := range unique {
+		results[i].Err = errs[k]
+	}
+	for i, idx := range indices {
+		if j := first[idx]; j != i {
+			results[i] = results[j]
+		}
+	}
+	return results
+}
+
 // LastDisclosureIndex returns the index of the most recently published
 // disclosure.
-func (c *Client) LastDisclosureIndex(ctx context.Context) (string, error) {
+func (c *Client) LastDisclosureIndex(ctx context.Context) (int, error) {
 	var resp LastDisclosureIndexResponse
-	if err := c.get(ctx, "/api/vyk/lastDisclosureIndex", nil, &resp); err != nil {
-		return "", err
+	if err := c.get(ctx, "LastDisclosureIndex", "/api/vyk/lastDisclosureIndex", nil, &resp); err != nil {
+		return 0, err
 	}
-	return resp.LastDisclosureIndex, nil
+	return int(resp.LastDisclosureIndex), nil
 }
 
-// BlockedDisclosures returns the list of blocked disclosures and attachments.
-// The response schema is not fully documented, so the raw JSON is returned.
-func (c *Client) BlockedDisclosures(ctx context.Context) (json.RawMessage, error) {
-	var raw json.RawMessage
-	if err := c.get(ctx, "/api/vyk/blockedDisclosures", nil, &raw); err != nil {
+// BlockedDisclosures returns the list of blocked disclosures and
+// attachments. Blocked content must not be redistributed.
+func (c *Client) BlockedDisclosures(ctx context.Context) (*BlockedBase, error) {
+	var list BlockedBase
+	if err := c.get(ctx, "BlockedDisclosures", "/api/vyk/blockedDisclosures", nil, &list); err != nil {
 		return nil, err
 	}
-	return raw, nil
+	return &list, nil
+}
+
+// isZero reports whether p applies no filters.
+func (p *DisclosureListParams) isZero() bool {
+	return p == nil ||
+		p.DisclosureClass == "" && p.DisclosureType == "" && p.CompanyID == "" &&
+			len(p.DisclosureClasses) == 0 && len(p.DisclosureTypes) == 0 && len(p.CompanyIDs) == 0
 }
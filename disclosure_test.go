@@ -0,0 +1,72 @@
+package kap
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDisclosureListParamsQueries(t *testing.T) {
+	p := &DisclosureListParams{
+		DisclosureClass:   DisclosureClassFR,
+		DisclosureClasses: []DisclosureClass{DisclosureClassFR, DisclosureClassODA},
+		DisclosureTypes:   []DisclosureType{"DG", "FR"},
+		CompanyID:         "926",
+	}
+	var got []string
+	for _, q := range p.queries() {
+		if len(q["disclosureTypes"]) > 1 {
+			t.Errorf("query %s repeats disclosureTypes", q.Encode())
+		}
+		got = append(got, q.Encode())
+	}
+	want := []string{
+		"companyId=926&disclosureClass=FR&disclosureTypes=DG",
+		"companyId=926&disclosureClass=FR&disclosureTypes=FR",
+		"companyId=926&disclosureClass=ODA&disclosureTypes=DG",
+		"companyId=926&disclosureClass=ODA&disclosureTypes=FR",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("queries = %q, want %q", got, want)
+	}
+
+	if got := (*DisclosureListParams)(nil).queries(); len(got) != 1 || len(got[0]) != 0 {
+		t.Errorf("nil params queries = %v", got)
+	}
+}
+
+func TestDisclosuresMergesTypes(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		types []string
+	)
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		typ := r.URL.Query().Get("disclosureTypes")
+		mu.Lock()
+		types = append(types, typ)
+		mu.Unlock()
+		// Both types list disclosure 11; each lists one other.
+		other := map[string]int{"DG": 10, "FR": 12}[typ]
+		fmt.Fprintf(w, `[{"disclosureIndex":"%d","disclosureType":%q},{"disclosureIndex":"11"}]`, other, typ)
+	})
+
+	list, err := c.Disclosures(context.Background(), 10, &DisclosureListParams{DisclosureTypes: []DisclosureType{"DG", "FR"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(types)
+	if got := strings.Join(types, ","); got != "DG,FR" {
+		t.Errorf("requested types %s, want DG,FR", got)
+	}
+	var indices []int
+	for _, d := range list {
+		indices = append(indices, int(d.DisclosureIndex))
+	}
+	if !slices.Equal(indices, []int{10, 11, 12}) {
+		t.Errorf("indices = %v, want [10 11 12]", indices)
+	}
+}
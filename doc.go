@@ -7,10 +7,14 @@
 //
 // # Production usage
 //
-// In production, authenticate with your API key and generate a bearer token:
+// In production, authenticate with your API key. The client generates a
+// bearer token on first use, refreshes it before its 24-hour validity ends,
+// and regenerates it once when the API rejects it as expired or invalid:
 //
 //	client := kap.NewClient("YOUR-API-KEY")
-//	token, err := client.GenerateToken(ctx)
+//	disclosures, err := client.Disclosures(ctx, 1092228, nil)
+//
+// GenerateToken can still be called to obtain a token explicitly.
 //
 // # Test environment
 //
@@ -21,7 +25,7 @@
 // # Fetching disclosures
 //
 //	disclosures, err := client.Disclosures(ctx, 1092228, nil)
-//	detail, err := client.DisclosureDetail(ctx, 1211180, "data", "")
+//	detail, err := client.DisclosureDetail(ctx, 1211180, "data", nil)
 //
 // All methods accept a context.Context for cancellation and timeout control.
 // Errors returned by the API are represented as *APIError values which
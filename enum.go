@@ -0,0 +1,380 @@
+package kap
+
+import "strings"
+
+// Language selects the language of a code description.
+type Language string
+
+// Supported description languages.
+const (
+	Turkish Language = "tr"
+	English Language = "en"
+)
+
+// label holds the Turkish and English descriptions of a code.
+type label struct {
+	tr string
+	en string
+}
+
+// text returns the description in lang, defaulting to English.
+func (l label) text(lang Language) string {
+	if lang == Turkish {
+		return l.tr
+	}
+	return l.en
+}
+
+// DisclosureClass is the class of a disclosure.
+type DisclosureClass string
+
+// Disclosure classes.
+const (
+	DisclosureClassFR  DisclosureClass = "FR"
+	DisclosureClassODA DisclosureClass = "ODA"
+	DisclosureClassDG  DisclosureClass = "DG"
+	DisclosureClassDUY DisclosureClass = "DUY"
+)
+
+var disclosureClassLabels = map[DisclosureClass]label{
+	DisclosureClassFR:  {"Finansal Rapor Bildirimi", "Financial Report Disclosure"},
+	DisclosureClassODA: {"Özel Durum Açıklaması Bildirimi", "Material Event Disclosure"},
+	DisclosureClassDG:  {"Diğer Bildirim", "Other Disclosure"},
+	DisclosureClassDUY: {"Düzenleyici Kurum Bildirimi", "Regulatory Authority Disclosure"},
+}
+
+// IsValid reports whether c is a documented disclosure class.
+func (c DisclosureClass) IsValid() bool {
+	_, ok := disclosureClassLabels[c]
+	return ok
+}
+
+// Description returns the label of c in lang, or "" if c is unknown.
+func (c DisclosureClass) Description(lang Language) string {
+	return disclosureClassLabels[c].text(lang)
+}
+
+// DisclosureType is the type of a disclosure.
+type DisclosureType string
+
+// Disclosure types.
+const (
+	DisclosureTypeFR  DisclosureType = "FR"
+	DisclosureTypeODA DisclosureType = "ODA"
+	DisclosureTypeDG  DisclosureType = "DG"
+	DisclosureTypeDUY DisclosureType = "DUY"
+	DisclosureTypeFON DisclosureType = "FON"
+	DisclosureTypeCA  DisclosureType = "CA"
+)
+
+var disclosureTypeLabels = map[DisclosureType]label{
+	DisclosureTypeFR:  {"Finansal Rapor Bildirimi", "Financial Report Disclosure"},
+	DisclosureTypeODA: {"Özel Durum Açıklaması Bildirimi", "Material Event Disclosure"},
+	DisclosureTypeDG:  {"Diğer Bildirim", "Other Disclosure"},
+	DisclosureTypeDUY: {"Düzenleyici Kurum Bildirimi", "Regulatory Authority Disclosure"},
+	DisclosureTypeFON: {"Fon Bildirimi", "Fund Disclosure"},
+	DisclosureTypeCA:  {"Hak Kullanım Bildirimi", "Corporate Action Disclosure"},
+}
+
+// IsValid reports whether t is a documented disclosure type.
+func (t DisclosureType) IsValid() bool {
+	_, ok := disclosureTypeLabels[t]
+	return ok
+}
+
+// Description returns the label of t in lang, or "" if t is unknown.
+func (t DisclosureType) Description(lang Language) string {
+	return disclosureTypeLabels[t].text(lang)
+}
+
+// DisclosureReason is the publication reason of a disclosure.
+type DisclosureReason string
+
+// Disclosure reasons.
+const (
+	DisclosureReasonNew          DisclosureReason = "NEW"
+	DisclosureReasonUpdate       DisclosureReason = "UPD"
+	DisclosureReasonCorrection   DisclosureReason = "CORR"
+	DisclosureReasonCancellation DisclosureReason = "CANC"
+)
+
+var disclosureReasonLabels = map[DisclosureReason]label{
+	DisclosureReasonNew:          {"Yeni", "New"},
+	DisclosureReasonUpdate:       {"Güncelleme", "Update"},
+	DisclosureReasonCorrection:   {"Düzeltme", "Correction"},
+	DisclosureReasonCancellation: {"İptal", "Cancellation"},
+}
+
+// IsValid reports whether r is a documented disclosure reason.
+func (r DisclosureReason) IsValid() bool {
+	_, ok := disclosureReasonLabels[r]
+	return ok
+}
+
+// Description returns the label of r in lang, or "" if r is unknown.
+func (r DisclosureReason) Description(lang Language) string {
+	return disclosureReasonLabels[r].text(lang)
+}
+
+// MemberType is the type of a KAP member company. The API may return
+// several types in one value, separated by commas; use Split to get them
+// individually.
+type MemberType string
+
+// Member types.
+const (
+	MemberTypeIGS  MemberType = "IGS"
+	MemberTypeIGMS MemberType = "IGMS"
+	MemberTypeYK   MemberType = "YK"
+	MemberTypePYS  MemberType = "PYS"
+	MemberTypeDDK  MemberType = "DDK"
+	MemberTypeFK   MemberType = "FK"
+	MemberTypeBDK  MemberType = "BDK"
+	MemberTypeDCS  MemberType = "DCS"
+	MemberTypeDS   MemberType = "DS"
+	MemberTypeDG   MemberType = "DG"
+)
+
+var memberTypeLabels = map[MemberType]label{
+	MemberTypeIGS:  {"İşlem Gören Şirket", "Listed Company"},
+	MemberTypeIGMS: {"İşlem Görmeyen Şirket", "Unlisted Company"},
+	MemberTypeYK:   {"Yatırım Kuruluşu", "Investment Firm"},
+	MemberTypePYS:  {"Portföy Yönetim Şirketi", "Portfolio Management Company"},
+	MemberTypeDDK:  {"Düzenleyici Denetleyici Kurum", "Regulatory Supervisory Authority"},
+	MemberTypeFK:   {"Fon Kurucu - Temsilci", "Fund Founder - Representative"},
+	MemberTypeBDK:  {"Bağımsız Denetim Kuruluşu", "Independent Audit Firm"},
+	MemberTypeDCS:  {"Derecelendirme Şirketi", "Rating Agency"},
+	MemberTypeDS:   {"Değerlendirme Şirketi", "Valuation Company"},
+	MemberTypeDG:   {"Diğer", "Other"},
+}
+
+// IsValid reports whether t is a single documented member type.
+func (t MemberType) IsValid() bool {
+	_, ok := memberTypeLabels[t]
+	return ok
+}
+
+// Description returns the label of t in lang, or "" if t is unknown. For a
+// combined value the labels of its parts are joined with ", ".
+func (t MemberType) Description(lang Language) string {
+	parts := t.Split()
+	labels := make([]string, 0, len(parts))
+	for _, p := range parts {
+		l, ok := memberTypeLabels[p]
+		if !ok {
+			return ""
+		}
+		labels = append(labels, l.text(lang))
+	}
+	return strings.Join(labels, ", ")
+}
+
+// Split returns the individual types of a combined value such as
+// "FK, PYS".
+func (t MemberType) Split() []MemberType {
+	var types []MemberType
+	for _, p := range strings.Split(string(t), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			types = append(types, MemberType(p))
+		}
+	}
+	return types
+}
+
+// FundType is the type of a fund.
+type FundType string
+
+// Fund types.
+const (
+	FundTypeSYF FundType = "SYF"
+	FundTypeKGF FundType = "KGF"
+	FundTypeEYF FundType = "EYF"
+	FundTypeOKS FundType = "OKS"
+	FundTypeYYF FundType = "YYF"
+	FundTypeBYF FundType = "BYF"
+	FundTypeVFF FundType = "VFF"
+	FundTypeKFF FundType = "KFF"
+	FundTypeGMF FundType = "GMF"
+	FundTypeGSF FundType = "GSF"
+	FundTypePFF FundType = "PFF"
+
+	// FundTypeYF is not in the reference table but appears in fund list
+	// responses.
+	FundTypeYF FundType = "YF"
+)
+
+var fundTypeLabels = map[FundType]label{
+	FundTypeSYF: {"Şemsiye Yatırım Fonu (YF)", "Umbrella Investment Fund"},
+	FundTypeKGF: {"Koruma Amaçlı - Garantili Şemsiye YF", "Capital Protected - Guaranteed Fund"},
+	FundTypeEYF: {"Emeklilik Yatırım Fonu (EYF)", "Pension Investment Fund"},
+	FundTypeOKS: {"OKS Emeklilik Yatırım Fonu", "Auto-Enrollment Pension Fund"},
+	FundTypeYYF: {"Yabancı Yatırım Fonu (YYF)", "Foreign Investment Fund"},
+	FundTypeBYF: {"Borsa Yatırım Fonu (BYF)", "Exchange Traded Fund (ETF)"},
+	FundTypeVFF: {"Varlık Finansman Fonları (VFF)", "Asset Finance Funds"},
+	FundTypeKFF: {"Konut Finansman Fonları (KFF)", "Housing Finance Funds"},
+	FundTypeGMF: {"Gayrimenkul Yatırım Fonları (GMF)", "Real Estate Investment Funds"},
+	FundTypeGSF: {"Girişim Sermayesi Yatırım Fonu (GSF)", "Venture Capital Investment Fund"},
+	FundTypePFF: {"Proje Finansman Fonu (PFF)", "Project Finance Fund"},
+	FundTypeYF:  {"Yatırım Fonu (YF)", "Investment Fund"},
+}
+
+// IsValid reports whether t is a known fund type.
+func (t FundType) IsValid() bool {
+	_, ok := fundTypeLabels[t]
+	return ok
+}
+
+// Description returns the label of t in lang, or "" if t is unknown.
+func (t FundType) Description(lang Language) string {
+	return fundTypeLabels[t].text(lang)
+}
+
+// FundClass is the class of a fund.
+type FundClass string
+
+// Fund classes.
+const (
+	FundClassDG  FundClass = "DG"
+	FundClassPFF FundClass = "PFF"
+	FundClassKTF FundClass = "KTF"
+	FundClassHS  FundClass = "HS"
+	FundClassSF  FundClass = "SF"
+)
+
+var fundClassLabels = map[FundClass]label{
+	FundClassDG:  {"Diğer", "Other"},
+	FundClassPFF: {"Proje Finansman Fonu", "Project Finance Fund"},
+	FundClassKTF: {"Katılım Fonu", "Participation Fund"},
+	FundClassHS:  {"Hisse Yoğun", "Equity Heavy"},
+	FundClassSF:  {"Serbest Fon", "Hedge Fund"},
+}
+
+// IsValid reports whether c is a documented fund class.
+func (c FundClass) IsValid() bool {
+	_, ok := fundClassLabels[c]
+	return ok
+}
+
+// Description returns the label of c in lang, or "" if c is unknown.
+func (c FundClass) Description(lang Language) string {
+	return fundClassLabels[c].text(lang)
+}
+
+// FundState is the status of a fund.
+type FundState string
+
+// Fund states.
+const (
+	FundStateActive      FundState = "Y"
+	FundStatePassive     FundState = "N"
+	FundStateLiquidation FundState = "T"
+)
+
+var fundStateLabels = map[FundState]label{
+	FundStateActive:      {"Aktif", "Active"},
+	FundStatePassive:     {"Pasif", "Passive"},
+	FundStateLiquidation: {"Tasfiye", "Liquidation"},
+}
+
+// IsValid reports whether s is a documented fund state.
+func (s FundState) IsValid() bool {
+	_, ok := fundStateLabels[s]
+	return ok
+}
+
+// Description returns the label of s in lang, or "" if s is unknown.
+func (s FundState) Description(lang Language) string {
+	return fundStateLabels[s].text(lang)
+}
+
+// FundExpiry is the maturity type of a fund.
+type FundExpiry string
+
+// Fund maturity types.
+const (
+	FundExpiryFixedTerm FundExpiry = "VL"
+	FundExpiryOpenEnded FundExpiry = "VS"
+)
+
+var fundExpiryLabels = map[FundExpiry]label{
+	FundExpiryFixedTerm: {"Vadeli", "Fixed-term"},
+	FundExpiryOpenEnded: {"Vadesiz", "Open-ended"},
+}
+
+// IsValid reports whether e is a documented fund maturity type.
+func (e FundExpiry) IsValid() bool {
+	_, ok := fundExpiryLabels[e]
+	return ok
+}
+
+// Description returns the label of e in lang, or "" if e is unknown.
+func (e FundExpiry) Description(lang Language) string {
+	return fundExpiryLabels[e].text(lang)
+}
+
+// Consolidation is the consolidation basis of a financial report.
+type Consolidation string
+
+// Consolidation bases.
+const (
+	Consolidated   Consolidation = "CS"
+	Unconsolidated Consolidation = "NC"
+)
+
+var consolidationLabels = map[Consolidation]label{
+	Consolidated:   {"Konsolide", "Consolidated"},
+	Unconsolidated: {"Konsolide Olmayan", "Unconsolidated"},
+}
+
+// IsValid reports whether c is a documented consolidation basis.
+func (c Consolidation) IsValid() bool {
+	_, ok := consolidationLabels[c]
+	return ok
+}
+
+// Description returns the label of c in lang, or "" if c is unknown.
+func (c Consolidation) Description(lang Language) string {
+	return consolidationLabels[c].text(lang)
+}
+
+// FiscalPeriod is the reporting period of a periodic disclosure, as the
+// number of months from the start of the fiscal year.
+type FiscalPeriod int
+
+// Fiscal periods.
+const (
+	FiscalPeriodThreeMonths FiscalPeriod = 3
+	FiscalPeriodSixMonths   FiscalPeriod = 6
+	FiscalPeriodNineMonths  FiscalPeriod = 9
+	FiscalPeriodAnnual      FiscalPeriod = 12
+)
+
+var fiscalPeriodLabels = map[FiscalPeriod]label{
+	FiscalPeriodThreeMonths: {"3 Aylık", "3 Months"},
+	FiscalPeriodSixMonths:   {"6 Aylık", "6 Months"},
+	FiscalPeriodNineMonths:  {"9 Aylık", "9 Months"},
+	FiscalPeriodAnnual:      {"Yıllık", "Annual"},
+}
+
+// ParseFiscalPeriod parses a period label such as "9 Months", "6 Aylık" or
+// "Yıllık". It returns 0 if text is not a known period.
+func ParseFiscalPeriod(text string) FiscalPeriod {
+	text = strings.TrimSpace(text)
+	for p, l := range fiscalPeriodLabels {
+		if strings.EqualFold(text, l.tr) || strings.EqualFold(text, l.en) {
+			return p
+		}
+	}
+	return 0
+}
+
+// IsValid reports whether p is a documented fiscal period.
+func (p FiscalPeriod) IsValid() bool {
+	_, ok := fiscalPeriodLabels[p]
+	return ok
+}
+
+// Description returns the label of p in lang, or "" if p is unknown.
+func (p FiscalPeriod) Description(lang Language) string {
+	return fiscalPeriodLabels[p].text(lang)
+}
@@ -0,0 +1,118 @@
+package kap
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestEnumDescription(t *testing.T) {
+	tests := []struct {
+		name   string
+		valid  bool
+		tr, en string
+	}{
+		{"DisclosureClass", DisclosureClassODA.IsValid(), DisclosureClassODA.Description(Turkish), DisclosureClassODA.Description(English)},
+		{"DisclosureType", DisclosureTypeFON.IsValid(), DisclosureTypeFON.Description(Turkish), DisclosureTypeFON.Description(English)},
+		{"DisclosureReason", DisclosureReasonCorrection.IsValid(), DisclosureReasonCorrection.Description(Turkish), DisclosureReasonCorrection.Description(English)},
+		{"FundType", FundTypeBYF.IsValid(), FundTypeBYF.Description(Turkish), FundTypeBYF.Description(English)},
+		{"FundClass", FundClassKTF.IsValid(), FundClassKTF.Description(Turkish), FundClassKTF.Description(English)},
+		{"FundState", FundStateLiquidation.IsValid(), FundStateLiquidation.Description(Turkish), FundStateLiquidation.Description(English)},
+		{"FundExpiry", FundExpiryOpenEnded.IsValid(), FundExpiryOpenEnded.Description(Turkish), FundExpiryOpenEnded.Description(English)},
+		{"Consolidation", Consolidated.IsValid(), Consolidated.Description(Turkish), Consolidated.Description(English)},
+		{"FiscalPeriod", FiscalPeriodNineMonths.IsValid(), FiscalPeriodNineMonths.Description(Turkish), FiscalPeriodNineMonths.Description(English)},
+	}
+	want := map[string][2]string{
+		"DisclosureClass":  {"Özel Durum Açıklaması Bildirimi", "Material Event Disclosure"},
+		"DisclosureType":   {"Fon Bildirimi", "Fund Disclosure"},
+		"DisclosureReason": {"Düzeltme", "Correction"},
+		"FundType":         {"Borsa Yatırım Fonu (BYF)", "Exchange Traded Fund (ETF)"},
+		"FundClass":        {"Katılım Fonu", "Participation Fund"},
+		"FundState":        {"Tasfiye", "Liquidation"},
+		"FundExpiry":       {"Vadesiz", "Open-ended"},
+		"Consolidation":    {"Konsolide", "Consolidated"},
+		"FiscalPeriod":     {"9 Aylık", "9 Months"},
+	}
+	for _, tt := range tests {
+		if !tt.valid {
+			t.Errorf("%s: IsValid = false", tt.name)
+		}
+		if w := want[tt.name]; tt.tr != w[0] || tt.en != w[1] {
+			t.Errorf("%s: Description = %q, %q, want %q, %q", tt.name, tt.tr, tt.en, w[0], w[1])
+		}
+	}
+}
+
+func TestEnumUnknown(t *testing.T) {
+	if DisclosureClass("XX").IsValid() || DisclosureType("").IsValid() || FundState("Z").IsValid() ||
+		MemberType("IGS,XX").IsValid() || FiscalPeriod(4).IsValid() {
+		t.Error("unknown value reported as valid")
+	}
+	if d := DisclosureType("XX").Description(English); d != "" {
+		t.Errorf("Description of unknown type = %q, want empty", d)
+	}
+	if d := MemberType("IGS, XX").Description(English); d != "" {
+		t.Errorf("Description of combined type with an unknown part = %q, want empty", d)
+	}
+}
+
+func TestMemberTypeSplit(t *testing.T) {
+	mt := MemberType("FK, PYS")
+	if got := mt.Split(); !slices.Equal(got, []MemberType{MemberTypeFK, MemberTypePYS}) {
+		t.Errorf("Split = %q", got)
+	}
+	if mt.IsValid() {
+		t.Error("combined value reported as a single valid type")
+	}
+	if got, want := mt.Description(English), "Fund Founder - Representative, Portfolio Management Company"; got != want {
+		t.Errorf("Description = %q, want %q", got, want)
+	}
+	if got := MemberType("").Split(); len(got) != 0 {
+		t.Errorf("Split of empty value = %q", got)
+	}
+}
+
+func TestParseFiscalPeriod(t *testing.T) {
+	tests := map[string]FiscalPeriod{
+		"9 Months": FiscalPeriodNineMonths,
+		" 6 aylık": FiscalPeriodSixMonths,
+		"Yıllık":   FiscalPeriodAnnual,
+		"annual":   FiscalPeriodAnnual,
+		"2 Months": 0,
+	}
+	for text, want := range tests {
+		if got := ParseFiscalPeriod(text); got != want {
+			t.Errorf("ParseFiscalPeriod(%q) = %d, want %d", text, got, want)
+		}
+	}
+}
+
+func TestEnumUnmarshal(t *testing.T) {
+	var d Disclosure
+	if err := json.Unmarshal([]byte(`{"disclosureType":"FON","disclosureClass":"NEW"}`), &d); err != nil {
+		t.Fatal(err)
+	}
+	if d.DisclosureType != DisclosureTypeFON {
+		t.Errorf("DisclosureType = %q", d.DisclosureType)
+	}
+	// Values added to the API after this package keep their code.
+	if d.DisclosureClass != "NEW" || d.DisclosureClass.IsValid() {
+		t.Errorf("DisclosureClass = %q, want the undocumented code kept", d.DisclosureClass)
+	}
+
+	var f Fund
+	if err := json.Unmarshal([]byte(`{"fundType":"YF","fundClass":"SF","fundExpiry":"VL","fundState":"Y"}`), &f); err != nil {
+		t.Fatal(err)
+	}
+	if f.FundType != FundTypeYF || f.FundClass != FundClassSF || f.FundExpiry != FundExpiryFixedTerm || f.FundState != FundStateActive {
+		t.Errorf("fund = %+v", f)
+	}
+
+	var m Member
+	if err := json.Unmarshal([]byte(`{"memberType":"FK, PYS"}`), &m); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.MemberType.Split()) != 2 {
+		t.Errorf("MemberType = %q", m.MemberType)
+	}
+}
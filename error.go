@@ -3,6 +3,7 @@ package kap
 import (
 	"errors"
 	"fmt"
+	"time"
 )
 
 // Sentinel errors for KAP API error codes (ER001–ER008).
@@ -35,6 +36,8 @@ type APIError struct {
 	Code       string `json:"code"`
 	Message    string `json:"message"`
 	HTTPStatus int    `json:"-"`
+
+	retryAfter time.Duration
 }
 
 func (e *APIError) Error() string {
@@ -56,10 +59,18 @@ type RequestError struct {
 	Method string
 	Path   string
 	Err    error
+
+	// HTTPStatus is the response status code when the failure is a non-2xx
+	// response whose body is not a KAP error object. It is zero otherwise.
+	HTTPStatus int
+
+	retryAfter time.Duration
 }
 
+// Error returns the error message with secrets such as the API key
+// masked.
 func (e *RequestError) Error() string {
-	return fmt.Sprintf("kap: %s %s: %v", e.Method, e.Path, e.Err)
+	return redactSecrets(fmt.Sprintf("kap: %s %s: %v", e.Method, e.Path, e.Err))
 }
 
 func (e *RequestError) Unwrap() error {
@@ -12,7 +12,7 @@ func (c *Client) CAEventStatus(ctx context.Context, processRefID string) (*CAEve
 	q.Set("processRefId", processRefID)
 
 	var status CAEventStatus
-	if err := c.get(ctx, "/api/vyk/caEventStatus", q, &status); err != nil {
+	if err := c.get(ctx, "CAEventStatus", "/api/vyk/caEventStatus", q, &status); err != nil {
 		return nil, err
 	}
 	return &status, nil
@@ -33,7 +33,7 @@ func main() {
 	if err != nil {
 		log.Fatalf("  FAIL: %v", err)
 	}
-	fmt.Printf("  Last disclosure index: %s\n\n", lastIndex)
+	fmt.Printf("  Last disclosure index: %d\n\n", lastIndex)
 
 	// 2. Disclosures (start from test range)
 	fmt.Println("=== 2. Disclosures ===")
@@ -43,7 +43,7 @@ func main() {
 	}
 	fmt.Printf("  Fetched %d disclosures\n", len(disclosures))
 	for i, d := range disclosures {
-		fmt.Printf("    [%s] %s (type: %s, class: %s)\n",
+		fmt.Printf("    [%d] %s (type: %s, class: %s)\n",
 			d.DisclosureIndex, d.Title, d.DisclosureType, d.DisclosureClass)
 		if i >= 4 {
 			fmt.Printf("    ... and %d more\n", len(disclosures)-5)
@@ -55,15 +55,15 @@ func main() {
 	// 3. Disclosures with filters
 	fmt.Println("=== 3. Disclosures (filtered) ===")
 	filtered, err := client.Disclosures(ctx, 1092228, &kap.DisclosureListParams{
-		DisclosureClass: "DG",
-		DisclosureType:  "DG",
+		DisclosureClass: kap.DisclosureClassDG,
+		DisclosureType:  kap.DisclosureTypeDG,
 	})
 	if err != nil {
 		log.Fatalf("  FAIL: %v", err)
 	}
 	fmt.Printf("  Fetched %d filtered disclosures (class=DG, type=DG)\n", len(filtered))
 	for i, d := range filtered {
-		fmt.Printf("    [%s] %s\n", d.DisclosureIndex, d.Title)
+		fmt.Printf("    [%d] %s\n", d.DisclosureIndex, d.Title)
 		if i >= 2 {
 			fmt.Printf("    ... and %d more\n", len(filtered)-3)
 			break
@@ -73,11 +73,11 @@ func main() {
 
 	// 4. Disclosure Detail
 	fmt.Println("=== 4. DisclosureDetail ===")
-	detail, err := client.DisclosureDetail(ctx, 1211180, "data", "")
+	detail, err := client.DisclosureDetail(ctx, 1211180, "data", nil)
 	if err != nil {
 		log.Fatalf("  FAIL: %v", err)
 	}
-	fmt.Printf("  Index: %s\n", detail.DisclosureIndex)
+	fmt.Printf("  Index: %d\n", detail.DisclosureIndex)
 	fmt.Printf("  Sender: %s\n", detail.SenderTitle)
 	fmt.Printf("  Type: %s, Class: %s\n", detail.DisclosureType, detail.DisclosureClass)
 	fmt.Printf("  Reason: %s\n", detail.DisclosureReason)
@@ -95,20 +95,17 @@ func main() {
 	fmt.Printf("  Presentations: %d\n", len(detail.Presentation))
 	fmt.Printf("  FlatData items: %d\n", len(detail.FlatData))
 	fmt.Printf("  HTML messages: %d\n", len(detail.HTMLMessages))
+	text, err := detail.Text(kap.English)
+	if err != nil {
+		log.Fatalf("  FAIL: %v", err)
+	}
+	fmt.Printf("  Text (EN): %d characters\n", len([]rune(text)))
 	fmt.Println()
 
 	// 5. Download Attachment
 	fmt.Println("=== 5. DownloadAttachment ===")
 	if len(detail.AttachmentURLs) > 0 {
-		attURL := detail.AttachmentURLs[0].URL
-		var attID string
-		for i := len(attURL) - 1; i >= 0; i-- {
-			if attURL[i] == '/' {
-				attID = attURL[i+1:]
-				break
-			}
-		}
-		body, disposition, err := client.DownloadAttachment(ctx, attID)
+		body, disposition, err := client.DownloadAttachmentURL(ctx, detail.AttachmentURLs[0])
 		if err != nil {
 			fmt.Printf("  ERROR: %v (non-fatal)\n\n", err)
 		} else {
@@ -128,12 +125,15 @@ func main() {
 	if err != nil {
 		log.Fatalf("  FAIL: %v", err)
 	}
-	fmt.Printf("  Response (%d bytes): %.200s", len(blocked), string(blocked))
-	if len(blocked) > 200 {
-		fmt.Print("...")
+	fmt.Printf("  Blocked entries: %d\n", len(blocked.Items))
+	for i, it := range blocked.Items {
+		fmt.Printf("    Index: %d, attachments: %d\n", it.DisclosureIndex, len(it.AttachmentIDs))
+		if i >= 2 {
+			fmt.Printf("    ... and %d more\n", len(blocked.Items)-3)
+			break
+		}
 	}
 	fmt.Println()
-	fmt.Println()
 
 	// 7. Members
 	fmt.Println("=== 7. Members ===")
@@ -174,8 +174,10 @@ func main() {
 	// 9. Member Detail
 	fmt.Println("=== 9. MemberDetail ===")
 	if len(members) > 0 {
-		var memberID int
-		fmt.Sscanf(members[0].ID, "%d", &memberID)
+		memberID, err := members[0].ID.Int()
+		if err != nil {
+			log.Fatalf("  FAIL: %v", err)
+		}
 		fields, err := client.MemberDetail(ctx, memberID)
 		if err != nil {
 			log.Fatalf("  FAIL: %v", err)
@@ -200,7 +202,7 @@ func main() {
 	}
 	fmt.Printf("  Found %d funds\n", len(funds))
 	for i, f := range funds {
-		fmt.Printf("    %s (ID: %d, code: %s, type: %s, state: %s)\n",
+		fmt.Printf("    %s (ID: %s, code: %s, type: %s, state: %s)\n",
 			f.FundName, f.FundID, f.FundCode, f.FundType, f.FundState)
 		if i >= 2 {
 			fmt.Printf("    ... and %d more\n", len(funds)-3)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/knckknckknck/kap-go"
 )
@@ -36,7 +37,7 @@ func ExampleClient_Disclosures() {
 	client := kap.NewClient("", kap.WithBasicAuth("user", "pass"))
 
 	disclosures, err := client.Disclosures(context.Background(), 1092228, &kap.DisclosureListParams{
-		DisclosureClass: "DG",
+		DisclosureClass: kap.DisclosureClassDG,
 	})
 	if err != nil {
 		log.Fatal(err)
@@ -49,9 +50,56 @@ func ExampleClient_Disclosures() {
 func ExampleClient_DisclosureDetail() {
 	client := kap.NewClient("", kap.WithBasicAuth("user", "pass"))
 
-	detail, err := client.DisclosureDetail(context.Background(), 1211180, "data", "")
+	detail, err := client.DisclosureDetail(context.Background(), 1211180, "data", nil)
 	if err != nil {
 		log.Fatal(err)
 	}
 	fmt.Println(detail.SenderTitle)
 }
+
+func ExampleDisclosureDetail_Text() {
+	client := kap.NewClient("", kap.WithBasicAuth("user", "pass"))
+
+	detail, err := client.DisclosureDetail(context.Background(), 1211180, "data", nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	text, err := detail.Text(kap.Turkish)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(text)
+}
+
+func ExampleDisclosurePager_All() {
+	client := kap.NewClient("", kap.WithBasicAuth("user", "pass"))
+	ctx := context.Background()
+
+	pager := client.DisclosurePager(1092228, 1092400, &kap.DisclosureListParams{
+		DisclosureClass: kap.DisclosureClassODA,
+	})
+	for d, err := range pager.All(ctx) {
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(d.DisclosureIndex, d.Title)
+	}
+	fmt.Println("Resume from:", pager.Cursor())
+}
+
+func ExampleWatcher_Run() {
+	client := kap.NewClient(os.Getenv("MKK_API_KEY"))
+
+	w := client.NewWatcher(&kap.WatcherOptions{
+		PollInterval: 2 * time.Second,
+		Checkpoint:   kap.NewFileCheckpoint("watcher.checkpoint"),
+		OnError:      func(err error) { log.Println("poll failed:", err) },
+	})
+	err := w.Run(context.Background(), func(d kap.Disclosure) error {
+		fmt.Println("new disclosure:", d.DisclosureIndex, d.Title)
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
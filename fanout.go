@@ -0,0 +1,39 @@
+package kap
+
+import (
+	"context"
+	"sync"
+)
+
+// forEach calls fn with each of 0 to n-1, running at most workers calls at
+// once, or all n if workers is zero or less. It returns the error of each
+// call by position. Once ctx is done no further calls are started, and the
+// error of each call not started is ctx.Err().
+func forEach(ctx context.Context, n, workers int, fn func(i int) error) []error {
+	if workers <= 0 || workers > n {
+		workers = n
+	}
+	errs := make([]error, n)
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i := range n {
+		if err := ctx.Err(); err != nil {
+			errs[i] = err
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errs[i] = fn(i)
+		}()
+	}
+	wg.Wait()
+	return errs
+}
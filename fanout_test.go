@@ -0,0 +1,83 @@
+package kap
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestForEach(t *testing.T) {
+	var running, peak atomic.Int32
+	errBoom := errors.New("boom")
+	errs := forEach(context.Background(), 20, 3, func(i int) error {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		running.Add(-1)
+		if i%5 == 0 {
+			return errBoom
+		}
+		return nil
+	})
+	if p := peak.Load(); p > 3 {
+		t.Errorf("peak concurrency = %d, want at most 3", p)
+	}
+	for i, err := range errs {
+		if want := i%5 == 0; errors.Is(err, errBoom) != want {
+			t.Errorf("errs[%d] = %v", i, err)
+		}
+	}
+}
+
+func TestForEachCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var calls atomic.Int32
+	errs := forEach(ctx, 5, 1, func(int) error {
+		calls.Add(1)
+		return nil
+	})
+	if n := calls.Load(); n != 0 {
+		t.Errorf("%d calls after cancellation", n)
+	}
+	for i, err := range errs {
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("errs[%d] = %v, want context.Canceled", i, err)
+		}
+	}
+}
+
+func TestDisclosureDetails(t *testing.T) {
+	var requests atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if strings.HasSuffix(r.URL.Path, "/3") {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{}`)) //nolint:errcheck // test server
+	})
+
+	results := c.DisclosureDetails(context.Background(), []int{1, 2, 1, 3}, "data", &DetailsOptions{Workers: 2})
+	if n := requests.Load(); n != 3 {
+		t.Errorf("%d requests, want 3", n)
+	}
+	for i, want := range []int{1, 2, 1, 3} {
+		r := results[i]
+		if r.Index != want {
+			t.Errorf("results[%d].Index = %d, want %d", i, r.Index, want)
+		}
+		if failed := want == 3; (r.Err != nil) != failed || (r.Detail == nil) != failed {
+			t.Errorf("results[%d] = %+v", i, r)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package kap
+
+import (
+	"errors"
+	"os"
+)
+
+// errLockUnsupported is returned by FileTokenStore.Lock on platforms
+// without advisory file locking.
+var errLockUnsupported = errors.New("file locking is not supported on this platform")
+
+func tryLockFile(*os.File) (bool, error) {
+	return false, errLockUnsupported
+}
+
+func unlockFile(*os.File) error {
+	return errLockUnsupported
+}
@@ -0,0 +1,364 @@
+package kap
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Errors returned by DisclosureDetail.FinancialReport.
+var (
+	ErrNotFinancialReport = errors.New("kap: disclosure is not a financial report")
+	ErrNoFinancialData    = errors.New("kap: disclosure has no financial statement data")
+)
+
+// LineItem is a standard financial statement line item.
+type LineItem string
+
+// Balance sheet line items.
+const (
+	LineItemCashAndCashEquivalents LineItem = "CashAndCashEquivalents"
+	LineItemTradeReceivables       LineItem = "TradeReceivables"
+	LineItemInventories            LineItem = "Inventories"
+	LineItemCurrentAssets          LineItem = "CurrentAssets"
+	LineItemPropertyPlantEquipment LineItem = "PropertyPlantAndEquipment"
+	LineItemIntangibleAssets       LineItem = "IntangibleAssets"
+	LineItemNoncurrentAssets       LineItem = "NoncurrentAssets"
+	LineItemTotalAssets            LineItem = "TotalAssets"
+	LineItemShortTermBorrowings    LineItem = "ShortTermBorrowings"
+	LineItemTradePayables          LineItem = "TradePayables"
+	LineItemCurrentLiabilities     LineItem = "CurrentLiabilities"
+	LineItemLongTermBorrowings     LineItem = "LongTermBorrowings"
+	LineItemNoncurrentLiabilities  LineItem = "NoncurrentLiabilities"
+	LineItemTotalLiabilities       LineItem = "TotalLiabilities"
+	LineItemIssuedCapital          LineItem = "IssuedCapital"
+	LineItemRetainedEarnings       LineItem = "RetainedEarnings"
+	LineItemParentEquity           LineItem = "EquityAttributableToOwnersOfParent"
+	LineItemNoncontrollingInterest LineItem = "NoncontrollingInterests"
+	LineItemTotalEquity            LineItem = "TotalEquity"
+	LineItemEquityAndLiabilities   LineItem = "TotalEquityAndLiabilities"
+)
+
+// Income statement line items.
+const (
+	LineItemRevenue                LineItem = "Revenue"
+	LineItemCostOfSales            LineItem = "CostOfSales"
+	LineItemGrossProfit            LineItem = "GrossProfit"
+	LineItemAdministrativeExpenses LineItem = "AdministrativeExpenses"
+	LineItemSellingExpenses        LineItem = "SellingExpenses"
+	LineItemResearchExpenses       LineItem = "ResearchAndDevelopmentExpenses"
+	LineItemOperatingProfit        LineItem = "OperatingProfit"
+	LineItemFinanceIncome          LineItem = "FinanceIncome"
+	LineItemFinanceCosts           LineItem = "FinanceCosts"
+	LineItemProfitBeforeTax        LineItem = "ProfitBeforeTax"
+	LineItemIncomeTax              LineItem = "IncomeTaxExpense"
+	LineItemNetProfit              LineItem = "NetProfit"
+	LineItemParentNetProfit        LineItem = "NetProfitAttributableToOwnersOfParent"
+	LineItemBasicEPS               LineItem = "BasicEarningsPerShare"
+	LineItemDilutedEPS             LineItem = "DilutedEarningsPerShare"
+)
+
+// Cash flow statement line items.
+const (
+	LineItemDepreciation       LineItem = "DepreciationAndAmortisation"
+	LineItemOperatingCashFlow  LineItem = "OperatingCashFlow"
+	LineItemCapitalExpenditure LineItem = "CapitalExpenditure"
+	LineItemInvestingCashFlow  LineItem = "InvestingCashFlow"
+	LineItemDividendsPaid      LineItem = "DividendsPaid"
+	LineItemFinancingCashFlow  LineItem = "FinancingCashFlow"
+	LineItemNetChangeInCash    LineItem = "NetChangeInCash"
+)
+
+// lineItemConcept maps a line item to the IFRS concepts, by local name,
+// that report it, in order of preference.
+type lineItemConcept struct {
+	item     LineItem
+	concepts []string
+}
+
+var balanceSheetConcepts = []lineItemConcept{
+	{LineItemCashAndCashEquivalents, []string{"CashAndCashEquivalents"}},
+	{LineItemTradeReceivables, []string{"CurrentTradeReceivables", "TradeAndOtherCurrentReceivables"}},
+	{LineItemInventories, []string{"Inventories", "CurrentInventoriesHeldForSale"}},
+	{LineItemCurrentAssets, []string{"CurrentAssets"}},
+	{LineItemPropertyPlantEquipment, []string{"PropertyPlantAndEquipment"}},
+	{LineItemIntangibleAssets, []string{"IntangibleAssetsOtherThanGoodwill", "IntangibleAssetsAndGoodwill"}},
+	{LineItemNoncurrentAssets, []string{"NoncurrentAssets"}},
+	{LineItemTotalAssets, []string{"Assets"}},
+	{LineItemShortTermBorrowings, []string{"ShorttermBorrowings", "CurrentBorrowings", "CurrentPortionOfLongtermBorrowings"}},
+	{LineItemTradePayables, []string{"TradeAndOtherCurrentPayablesToTradeSuppliers", "CurrentTradePayables", "TradeAndOtherCurrentPayables"}},
+	{LineItemCurrentLiabilities, []string{"CurrentLiabilities"}},
+	{LineItemLongTermBorrowings, []string{"LongtermBorrowings", "NoncurrentPortionOfNoncurrentBorrowings"}},
+	{LineItemNoncurrentLiabilities, []string{"NoncurrentLiabilities"}},
+	{LineItemTotalLiabilities, []string{"Liabilities"}},
+	{LineItemIssuedCapital, []string{"IssuedCapital"}},
+	{LineItemRetainedEarnings, []string{"RetainedEarnings"}},
+	{LineItemParentEquity, []string{"EquityAttributableToOwnersOfParent"}},
+	{LineItemNoncontrollingInterest, []string{"NoncontrollingInterests"}},
+	{LineItemTotalEquity, []string{"Equity"}},
+	{LineItemEquityAndLiabilities, []string{"EquityAndLiabilities"}},
+}
+
+var incomeStatementConcepts = []lineItemConcept{
+	{LineItemRevenue, []string{"Revenue", "RevenueFromContractsWithCustomers"}},
+	{LineItemCostOfSales, []string{"CostOfSales"}},
+	{LineItemGrossProfit, []string{"GrossProfit"}},
+	{LineItemAdministrativeExpenses, []string{"AdministrativeExpense", "GeneralAndAdministrativeExpense"}},
+	{LineItemSellingExpenses, []string{"SellingGeneralAndAdministrativeExpense", "DistributionCosts", "SellingExpense"}},
+	{LineItemResearchExpenses, []string{"ResearchAndDevelopmentExpense"}},
+	{LineItemOperatingProfit, []string{"ProfitLossFromOperatingActivities"}},
+	{LineItemFinanceIncome, []string{"FinanceIncome"}},
+	{LineItemFinanceCosts, []string{"FinanceCosts"}},
+	{LineItemProfitBeforeTax, []string{"ProfitLossBeforeTax", "ProfitLossFromContinuingOperationsBeforeTax"}},
+	{LineItemIncomeTax, []string{"IncomeTaxExpenseContinuingOperations", "TaxIncomeExpense"}},
+	{LineItemNetProfit, []string{"ProfitLoss"}},
+	{LineItemParentNetProfit, []string{"ProfitLossAttributableToOwnersOfParent"}},
+	{LineItemBasicEPS, []string{"BasicEarningsLossPerShare", "BasicEarningsLossPerShareFromContinuingOperations"}},
+	{LineItemDilutedEPS, []string{"DilutedEarningsLossPerShare", "DilutedEarningsLossPerShareFromContinuingOperations"}},
+}
+
+var cashFlowConcepts = []lineItemConcept{
+	{LineItemDepreciation, []string{"AdjustmentsForDepreciationAndAmortisationExpense", "DepreciationAndAmortisationExpense"}},
+	{LineItemOperatingCashFlow, []string{"CashFlowsFromUsedInOperatingActivities"}},
+	{LineItemCapitalExpenditure, []string{"PurchaseOfPropertyPlantAndEquipmentClassifiedAsInvestingActivities", "PurchaseOfPropertyPlantAndEquipment"}},
+	{LineItemInvestingCashFlow, []string{"CashFlowsFromUsedInInvestingActivities"}},
+	{LineItemDividendsPaid, []string{"DividendsPaidClassifiedAsFinancingActivities", "DividendsPaid"}},
+	{LineItemFinancingCashFlow, []string{"CashFlowsFromUsedInFinancingActivities"}},
+	{LineItemNetChangeInCash, []string{"IncreaseDecreaseInCashAndCashEquivalents", "IncreaseDecreaseInCashAndCashEquivalentsBeforeEffectOfExchangeRateChanges"}},
+}
+
+// Line is the value of a line item in the current and prior periods. A nil
+// value was not reported.
+type Line struct {
+	// Concept is the concept the values were taken from, such as
+	// "ifrs-full_Revenue".
+	Concept string
+	Unit    string
+	Current *Decimal
+	Prior   *Decimal
+}
+
+// Statement is a financial statement keyed by line item. Line items the
+// report does not contain are absent.
+type Statement map[LineItem]Line
+
+// FinancialReport is the normalized financial statements of an FR
+// disclosure.
+type FinancialReport struct {
+	DisclosureIndex int
+	Year            int
+	Period          FiscalPeriod
+	Consolidation   Consolidation
+
+	BalanceSheet    Statement
+	IncomeStatement Statement
+	CashFlow        Statement
+}
+
+// FinancialReport extracts the balance sheet, income statement and cash
+// flow statement of an FR disclosure fetched with fileType "data". Values
+// are matched to line items by their IFRS concept. Balance sheet values
+// are taken at the latest and previous instants; income statement and
+// cash flow values are the cumulative amounts for the reporting period and
+// the same period of the prior year.
+//
+// It returns ErrNotFinancialReport for other disclosure classes and
+// ErrNoFinancialData if d has no presentation facts. If a fact of a
+// concept used for a line item has a value that is not a number, the
+// report is returned without it together with an error naming each such
+// fact.
+func (d *DisclosureDetail) FinancialReport() (*FinancialReport, error) {
+	if d.DisclosureClass != DisclosureClassFR {
+		return nil, fmt.Errorf("%w: %d has class %q", ErrNotFinancialReport, d.DisclosureIndex, d.DisclosureClass)
+	}
+	presentations, err := d.Presentations()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		facts []resolvedFact
+		errs  []error
+	)
+	for _, p := range presentations {
+		resolved, perrs := resolveFacts(p)
+		facts = append(facts, resolved...)
+		errs = append(errs, perrs...)
+	}
+	if len(facts) == 0 {
+		return nil, errors.Join(append(errs, fmt.Errorf("%w: %d", ErrNoFinancialData, d.DisclosureIndex))...)
+	}
+
+	r := &FinancialReport{
+		DisclosureIndex: int(d.DisclosureIndex),
+		Year:            int(d.Year),
+		Consolidation:   d.Consolidation,
+		BalanceSheet:    buildStatement(facts, balanceSheetConcepts),
+		IncomeStatement: buildStatement(facts, incomeStatementConcepts),
+		CashFlow:        buildStatement(facts, cashFlowConcepts),
+	}
+	if d.Period != nil {
+		for _, text := range []*string{d.Period.EN, d.Period.TR} {
+			if text != nil && r.Period == 0 {
+				r.Period = ParseFiscalPeriod(*text)
+			}
+		}
+	}
+	return r, errors.Join(errs...)
+}
+
+// resolvedFact is a numeric fact with its context.
+type resolvedFact struct {
+	Fact
+	local   string
+	value   Decimal
+	context Context
+}
+
+// resolveFacts returns the numeric facts of p with their contexts, and an
+// error for each fact of a line item concept whose value is not a number.
+// Other facts that are not numbers, such as text facts, are skipped.
+func resolveFacts(p *Presentation) ([]resolvedFact, []error) {
+	var (
+		facts []resolvedFact
+		errs  []error
+	)
+	for _, f := range p.Facts {
+		local := localName(f.Concept)
+		v, err := f.Decimal()
+		if err != nil {
+			if f.Value != "" && lineItemConceptKnown(local) {
+				errs = append(errs, fmt.Errorf("kap: fact %s in context %s: %w", f.Concept, f.ContextRef, err))
+			}
+			continue
+		}
+		c, _ := p.Context(f.ContextRef)
+		facts = append(facts, resolvedFact{Fact: f, local: local, value: v, context: c})
+	}
+	return facts, errs
+}
+
+// lineItemConceptKnown reports whether concept is used for a line item.
+func lineItemConceptKnown(concept string) bool {
+	for _, list := range [][]lineItemConcept{balanceSheetConcepts, incomeStatementConcepts, cashFlowConcepts} {
+		for _, lc := range list {
+			if slices.Contains(lc.concepts, concept) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// localName strips the taxonomy prefix from a concept such as
+// "ifrs-full_Revenue" or "ifrs-full:Revenue".
+func localName(concept string) string {
+	if i := strings.IndexAny(concept, ":_"); i >= 0 {
+		return concept[i+1:]
+	}
+	return concept
+}
+
+// buildStatement picks the current and prior value of each line item.
+func buildStatement(facts []resolvedFact, concepts []lineItemConcept) Statement {
+	s := Statement{}
+	for _, lc := range concepts {
+		for _, concept := range lc.concepts {
+			var matched []resolvedFact
+			for _, f := range facts {
+				if f.local == concept {
+					matched = append(matched, f)
+				}
+			}
+			if line, ok := pickPeriods(matched); ok {
+				s[lc.item] = line
+				break
+			}
+		}
+	}
+	return s
+}
+
+// pickPeriods selects the current and prior values among the facts of one
+// concept. When the contexts have dates, the current value is the one at
+// the latest date and the prior value the one at the latest earlier date;
+// among durations ending on the same date the longest is used. Otherwise
+// the context keys decide ("CURR…" and "PREV…" or "PRIOR…").
+func pickPeriods(facts []resolvedFact) (Line, bool) {
+	if len(facts) == 0 {
+		return Line{}, false
+	}
+
+	var current, prior *resolvedFact
+	for i := range facts {
+		f := &facts[i]
+		if periodEnd(f.context.Period).IsZero() {
+			continue
+		}
+		switch {
+		case current == nil || laterOrLonger(f, current):
+			if current != nil && periodEnd(current.context.Period).Before(periodEnd(f.context.Period)) {
+				prior = current
+			}
+			current = f
+		case periodEnd(f.context.Period).Before(periodEnd(current.context.Period)) && (prior == nil || laterOrLonger(f, prior)):
+			prior = f
+		}
+	}
+
+	if current == nil {
+		for i := range facts {
+			f := &facts[i]
+			key := strings.ToUpper(f.context.Key)
+			if key == "" {
+				key = strings.ToUpper(f.ContextRef)
+			}
+			switch {
+			case current == nil && strings.HasPrefix(key, "CURR"):
+				current = f
+			case prior == nil && (strings.HasPrefix(key, "PREV") || strings.HasPrefix(key, "PRIOR")):
+				prior = f
+			}
+		}
+	}
+	if current == nil && prior == nil {
+		if len(facts) > 1 {
+			return Line{}, false
+		}
+		current = &facts[0]
+	}
+
+	var line Line
+	if current != nil {
+		line.Concept, line.Unit = current.Concept, current.UnitRef
+		line.Current = &current.value
+	}
+	if prior != nil {
+		if line.Concept == "" {
+			line.Concept, line.Unit = prior.Concept, prior.UnitRef
+		}
+		line.Prior = &prior.value
+	}
+	return line, true
+}
+
+// laterOrLonger reports whether a ends after b, or ends on the same date
+// and covers a longer period.
+func laterOrLonger(a, b *resolvedFact) bool {
+	ea, eb := periodEnd(a.context.Period), periodEnd(b.context.Period)
+	if !ea.Equal(eb) {
+		return ea.After(eb)
+	}
+	sa, sb := a.context.Period.StartDate, b.context.Period.StartDate
+	return !sa.IsZero() && (sb.IsZero() || sa.Before(sb))
+}
+
+// periodEnd returns the instant of p or the end of its duration.
+func periodEnd(p Period) time.Time {
+	if p.IsInstant() {
+		return p.Instant
+	}
+	return p.EndDate
+}
@@ -0,0 +1,77 @@
+package kap
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// financialDetail returns an FR disclosure detail with the given facts.
+func financialDetail(facts string) *DisclosureDetail {
+	content := `{"ContextList":{"Context":[
+		{"id":"c1","Period":{"instant":"2023-09-30"}},
+		{"id":"c2","Period":{"instant":"2022-12-31"}},
+		{"id":"d1","Period":{"startDate":"2023-01-01","endDate":"2023-09-30"}},
+		{"id":"d2","Period":{"startDate":"2023-07-01","endDate":"2023-09-30"}},
+		{"id":"d3","Period":{"startDate":"2022-01-01","endDate":"2022-09-30"}}]},` + facts + `}`
+	return &DisclosureDetail{
+		DisclosureIndex: 5,
+		DisclosureClass: DisclosureClassFR,
+		Presentation:    []PresentationItem{{ID: "fr", Content: json.RawMessage(content)}},
+	}
+}
+
+func TestFinancialReport(t *testing.T) {
+	d := financialDetail(`
+		"ifrs-full_Assets":[{"contextRef":"c2","value":"900"},{"contextRef":"c1","value":"1000.50"}],
+		"ifrs-full_Revenue":[{"contextRef":"d2","value":"30"},{"contextRef":"d1","value":"90"},{"contextRef":"d3","value":"60"}],
+		"ifrs-full_BasicEarningsLossPerShare":[{"contextRef":"d1","value":"1.250"},{"contextRef":"d3","value":"123.456"}],
+		"kap_Note":"not a number"`)
+	r, err := d.FinancialReport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		st             Statement
+		item           LineItem
+		current, prior string
+	}{
+		{r.BalanceSheet, LineItemTotalAssets, "1000.50", "900"},
+		{r.IncomeStatement, LineItemRevenue, "90", "60"},
+		{r.IncomeStatement, LineItemBasicEPS, "1.250", "123.456"},
+	}
+	for _, tt := range tests {
+		line, ok := tt.st[tt.item]
+		if !ok || line.Current == nil || line.Prior == nil {
+			t.Errorf("%s = %+v, %v", tt.item, line, ok)
+			continue
+		}
+		if line.Current.String() != tt.current || line.Prior.String() != tt.prior {
+			t.Errorf("%s = %s, %s; want %s, %s", tt.item, line.Current, line.Prior, tt.current, tt.prior)
+		}
+	}
+}
+
+func TestFinancialReportInvalidFact(t *testing.T) {
+	r, err := financialDetail(`
+		"ifrs-full_Revenue":{"contextRef":"d1","value":"90"},
+		"ifrs-full_BasicEarningsLossPerShare":{"contextRef":"d1","value":"1,25"}`).FinancialReport()
+	if err == nil || !strings.Contains(err.Error(), "ifrs-full_BasicEarningsLossPerShare") {
+		t.Errorf("FinancialReport error = %v, want one naming the EPS fact", err)
+	}
+	if r == nil || r.IncomeStatement[LineItemRevenue].Current == nil {
+		t.Errorf("FinancialReport = %+v, want the revenue line", r)
+	}
+
+	_, err = financialDetail(`"ifrs-full_Revenue":{"contextRef":"d1","value":"n/a"}`).FinancialReport()
+	if !errors.Is(err, ErrNoFinancialData) || !strings.Contains(err.Error(), "ifrs-full_Revenue") {
+		t.Errorf("FinancialReport error = %v, want ErrNoFinancialData naming the revenue fact", err)
+	}
+
+	d := financialDetail(`"ifrs-full_Revenue":{"contextRef":"d1","value":"90"}`)
+	d.DisclosureClass = DisclosureClassODA
+	if _, err := d.FinancialReport(); !errors.Is(err, ErrNotFinancialReport) {
+		t.Errorf("FinancialReport of ODA error = %v", err)
+	}
+}
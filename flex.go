@@ -0,0 +1,105 @@
+package kap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The API documents several fields as integers, booleans or numbers but
+// sends some of them as strings, and the representation has changed
+// between responses. The types in this file accept either form.
+
+// Index is an integer, such as a disclosure index or a year, sent by the
+// API either as a JSON number or as a string of digits. It marshals as a
+// JSON number.
+type Index int
+
+// UnmarshalJSON decodes a number, a numeric string, "" or null.
+func (i *Index) UnmarshalJSON(data []byte) error {
+	s, ok, err := flexText(data)
+	if err != nil || !ok {
+		*i = 0
+		return err
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return fmt.Errorf("kap: invalid integer %s", data)
+	}
+	*i = Index(n)
+	return nil
+}
+
+// String returns i in decimal.
+func (i Index) String() string {
+	return strconv.Itoa(int(i))
+}
+
+// ID is an identifier, such as a company or fund ID, sent by the API
+// either as a JSON number or as a string. It keeps the digits exactly as
+// sent and marshals as a JSON string.
+type ID string
+
+// UnmarshalJSON decodes a string, a number, or null.
+func (id *ID) UnmarshalJSON(data []byte) error {
+	s, _, err := flexText(data)
+	*id = ID(s)
+	return err
+}
+
+// Int returns id as an integer, for use with methods such as MemberDetail
+// and FundDetail.
+func (id ID) Int() (int, error) {
+	n, err := strconv.Atoi(string(id))
+	if err != nil {
+		return 0, fmt.Errorf("kap: ID %q is not numeric", string(id))
+	}
+	return n, nil
+}
+
+// Flag is a boolean sent by the API either as a JSON boolean or as a
+// string such as "true", "E" (evet) or "Y". It marshals as a JSON boolean.
+type Flag bool
+
+// UnmarshalJSON decodes a boolean, a string, a number, or null. Strings
+// are matched case-insensitively: "true", "t", "1", "e", "evet", "y", "yes"
+// and "x" are true; "false", "f", "0", "h", "hayir", "hayır", "n", "no"
+// and "" are false.
+func (f *Flag) UnmarshalJSON(data []byte) error {
+	s, ok, err := flexText(data)
+	if err != nil || !ok {
+		*f = false
+		return err
+	}
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true", "t", "1", "e", "evet", "y", "yes", "x":
+		*f = true
+	case "false", "f", "0", "h", "hayir", "hayır", "n", "no", "":
+		*f = false
+	default:
+		return fmt.Errorf("kap: invalid boolean %s", data)
+	}
+	return nil
+}
+
+// flexText returns the text of a JSON scalar: the contents of a string, or
+// the literal of a number or boolean. ok is false for null and "".
+func flexText(data []byte) (string, bool, error) {
+	data = bytes.TrimSpace(data)
+	switch {
+	case len(data) == 0 || string(data) == "null":
+		return "", false, nil
+	case data[0] == '"':
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return "", false, err
+		}
+		s = strings.TrimSpace(s)
+		return s, s != "", nil
+	case data[0] == '{' || data[0] == '[':
+		return "", false, fmt.Errorf("kap: expected a scalar, got %s", data)
+	}
+	return string(data), true, nil
+}
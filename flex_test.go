@@ -0,0 +1,129 @@
+package kap
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestIndexUnmarshal(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Index
+	}{
+		{`42`, 42},
+		{`"42"`, 42},
+		{`" 1234567 "`, 1234567},
+		{`"-3"`, -3},
+		{`""`, 0},
+		{`null`, 0},
+	}
+	for _, tt := range tests {
+		i := Index(9)
+		if err := json.Unmarshal([]byte(tt.in), &i); err != nil {
+			t.Errorf("Unmarshal(%s): %v", tt.in, err)
+			continue
+		}
+		if i != tt.want {
+			t.Errorf("Unmarshal(%s) = %d, want %d", tt.in, i, tt.want)
+		}
+	}
+
+	for _, in := range []string{`"abc"`, `1.5`, `"1.000"`, `true`, `{}`, `[1]`} {
+		var i Index
+		if err := json.Unmarshal([]byte(in), &i); err == nil {
+			t.Errorf("Unmarshal(%s) = %d, want an error", in, i)
+		}
+	}
+
+	b, err := json.Marshal(struct{ I Index }{7})
+	if err != nil || string(b) != `{"I":7}` {
+		t.Errorf("Marshal = %s, %v, want a JSON number", b, err)
+	}
+}
+
+func TestIDUnmarshal(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ID
+	}{
+		{`"4028328c594bfd1a01594c1a4b0d0085"`, "4028328c594bfd1a01594c1a4b0d0085"},
+		{`1234`, "1234"},
+		{`"0012"`, "0012"},
+		{`null`, ""},
+		{`""`, ""},
+	}
+	for _, tt := range tests {
+		var id ID
+		if err := json.Unmarshal([]byte(tt.in), &id); err != nil {
+			t.Errorf("Unmarshal(%s): %v", tt.in, err)
+			continue
+		}
+		if id != tt.want {
+			t.Errorf("Unmarshal(%s) = %q, want %q", tt.in, id, tt.want)
+		}
+	}
+	var id ID
+	if err := json.Unmarshal([]byte(`{"a":1}`), &id); err == nil {
+		t.Error("Unmarshal of an object succeeded")
+	}
+
+	b, err := json.Marshal(struct{ ID ID }{"1234"})
+	if err != nil || string(b) != `{"ID":"1234"}` {
+		t.Errorf("Marshal = %s, %v, want a JSON string", b, err)
+	}
+}
+
+func TestIDInt(t *testing.T) {
+	if n, err := ID("1234").Int(); err != nil || n != 1234 {
+		t.Errorf("Int = %d, %v, want 1234", n, err)
+	}
+	for _, id := range []ID{"", "8acae2c5", "12 34"} {
+		if _, err := id.Int(); err == nil {
+			t.Errorf("ID(%q).Int succeeded", id)
+		}
+	}
+}
+
+func TestFlagUnmarshal(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Flag
+	}{
+		{`true`, true},
+		{`false`, false},
+		{`"true"`, true},
+		{`"E"`, true},
+		{`"evet"`, true},
+		{`"Y"`, true},
+		{`"x"`, true},
+		{`1`, true},
+		{`0`, false},
+		{`"H"`, false},
+		{`"Hayır"`, false},
+		{`"N"`, false},
+		{`""`, false},
+		{`null`, false},
+	}
+	for _, tt := range tests {
+		f := !tt.want
+		if err := json.Unmarshal([]byte(tt.in), &f); err != nil {
+			t.Errorf("Unmarshal(%s): %v", tt.in, err)
+			continue
+		}
+		if f != tt.want {
+			t.Errorf("Unmarshal(%s) = %v, want %v", tt.in, f, tt.want)
+		}
+	}
+
+	for _, in := range []string{`"maybe"`, `2`, `[]`} {
+		var f Flag
+		if err := json.Unmarshal([]byte(in), &f); err == nil {
+			t.Errorf("Unmarshal(%s) = %v, want an error", in, f)
+		}
+	}
+
+	b, err := json.Marshal(struct{ F Flag }{true})
+	if err != nil || string(b) != `{"F":true}` {
+		t.Errorf("Marshal = %s, %v, want a JSON boolean", b, err)
+	}
+}
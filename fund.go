@@ -13,18 +13,18 @@ func (c *Client) Funds(ctx context.Context, params *FundListParams) ([]Fund, err
 	if params != nil {
 		q = url.Values{}
 		for _, s := range params.FundState {
-			q.Add("fundState", s)
+			q.Add("fundState", string(s))
 		}
 		for _, s := range params.FundClass {
-			q.Add("fundClass", s)
+			q.Add("fundClass", string(s))
 		}
 		for _, s := range params.FundType {
-			q.Add("fundType", s)
+			q.Add("fundType", string(s))
 		}
 	}
 
 	var funds []Fund
-	if err := c.get(ctx, "/api/vyk/funds", q, &funds); err != nil {
+	if err := c.get(ctx, "Funds", "/api/vyk/funds", q, &funds); err != nil {
 		return nil, err
 	}
 	return funds, nil
@@ -35,7 +35,7 @@ func (c *Client) FundDetail(ctx context.Context, fundID int) ([]DetailField, err
 	path := "/api/vyk/fundDetail/" + strconv.Itoa(fundID)
 
 	var fields []DetailField
-	if err := c.get(ctx, path, nil, &fields); err != nil {
+	if err := c.get(ctx, "FundDetail", path, nil, &fields); err != nil {
 		return nil, err
 	}
 	return fields, nil
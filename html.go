@@ -0,0 +1,709 @@
+package kap
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// HTML returns the decoded HTML of m in lang, or "" if m has no content in
+// that language. Content that is not valid UTF-8 is decoded as
+// Windows-1254, the Turkish code page used by older disclosures.
+func (m HTMLMessage) HTML(lang Language) (string, error) {
+	s := m.EN
+	if lang == Turkish {
+		s = m.TR
+	}
+	if s == nil || *s == "" {
+		return "", nil
+	}
+	data, err := decodeBase64(*s)
+	if err != nil {
+		return "", fmt.Errorf("kap: decoding HTML message %s: %w", m.ID, err)
+	}
+	return decodeText(data), nil
+}
+
+// Text returns m in lang rendered as plain text by HTMLToText.
+func (m HTMLMessage) Text(lang Language) (string, error) {
+	s, err := m.HTML(lang)
+	if err != nil {
+		return "", err
+	}
+	return HTMLToText(s), nil
+}
+
+// HTML returns the decoded HTML of all of d's messages in lang, in order.
+func (d *DisclosureDetail) HTML(lang Language) (string, error) {
+	var parts []string
+	for _, m := range d.HTMLMessages {
+		s, err := m.HTML(lang)
+		if err != nil {
+			return "", err
+		}
+		if s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, "\n"), nil
+}
+
+// Text returns all of d's messages in lang rendered as plain text,
+// separated by blank lines.
+func (d *DisclosureDetail) Text(lang Language) (string, error) {
+	var parts []string
+	for _, m := range d.HTMLMessages {
+		s, err := m.Text(lang)
+		if err != nil {
+			return "", err
+		}
+		if s != "" {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, "\n\n"), nil
+}
+
+// HTMLToText renders an HTML document as readable plain text. Scripts,
+// styles and comments are dropped, entities are decoded, and whitespace is
+// collapsed, with block elements starting new lines and paragraphs
+// separated by blank lines. Tables are laid out as aligned columns; empty
+// rows and columns are removed, and single-column layout tables are
+// rendered as ordinary paragraphs.
+func HTMLToText(s string) string {
+	var r htmlRenderer
+	r.children(parseHTML(s))
+	return tidyText(r.w.String())
+}
+
+// decodeBase64 decodes standard base64, with or without padding, ignoring
+// line breaks and other whitespace.
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+	return base64.RawStdEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// decodeText converts data to a string, decoding it as Windows-1254 unless
+// it is valid UTF-8. A UTF-8 byte order mark is removed.
+func decodeText(data []byte) string {
+	if utf8.Valid(data) {
+		return strings.TrimPrefix(string(data), "\ufeff")
+	}
+	return decodeWindows1254(data)
+}
+
+// decodeWindows1254 converts Windows-1254 text to a string.
+func decodeWindows1254(data []byte) string {
+	var b strings.Builder
+	b.Grow(len(data) + len(data)/4)
+	for _, c := range data {
+		b.WriteRune(windows1254(c))
+	}
+	return b.String()
+}
+
+// windows1254 maps a Windows-1254 byte to its Unicode code point. Bytes
+// the code page leaves undefined map to the C1 control with the same value.
+func windows1254(c byte) rune {
+	switch {
+	case c >= 0x80 && c < 0xa0:
+		if r := cp1254High[c-0x80]; r != 0 {
+			return r
+		}
+	case c == 0xd0:
+		return 'Ğ'
+	case c == 0xdd:
+		return 'İ'
+	case c == 0xde:
+		return 'Ş'
+	case c == 0xf0:
+		return 'ğ'
+	case c == 0xfd:
+		return 'ı'
+	case c == 0xfe:
+		return 'ş'
+	}
+	return rune(c)
+}
+
+// cp1254High holds Windows-1254 code points 0x80 to 0x9f; zero marks an
+// undefined byte.
+var cp1254High = [32]rune{
+	'€', 0, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0, 0, 0,
+	0, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0, 0, 'Ÿ',
+}
+
+// htmlNode is an element or, when tag is "", a text node.
+type htmlNode struct {
+	tag      string
+	text     string
+	colspan  int
+	children []*htmlNode
+}
+
+var (
+	// voidElements never have content or end tags.
+	voidElements = setOf("area", "base", "br", "col", "embed", "hr", "img", "input", "link", "meta", "param", "source", "track", "wbr")
+
+	// rawTextElements have content that is not parsed as HTML and is
+	// dropped.
+	rawTextElements = setOf("script", "style", "title", "textarea", "xmp")
+
+	// droppedElements are parsed but not rendered.
+	droppedElements = setOf("head", "noscript", "template", "select", "object")
+
+	// paragraphElements are separated from their surroundings by a blank
+	// line.
+	paragraphElements = setOf("p", "h1", "h2", "h3", "h4", "h5", "h6", "ul", "ol", "dl", "blockquote", "pre", "hr", "table", "form", "fieldset")
+
+	// blockElements start on a new line.
+	blockElements = setOf("div", "li", "dt", "dd", "tr", "section", "article", "header", "footer", "nav", "aside", "main", "address", "center", "caption", "figure", "figcaption", "thead", "tbody", "tfoot", "body", "html")
+
+	// tableSections group rows inside a table.
+	tableSections = setOf("thead", "tbody", "tfoot")
+)
+
+func setOf(names ...string) map[string]bool {
+	m := make(map[string]bool, len(names))
+	for _, n := range names {
+		m[n] = true
+	}
+	return m
+}
+
+// parseHTML parses s into a tree leniently, closing elements the way
+// browsers do for the common cases of omitted end tags (p, li, td, tr).
+func parseHTML(s string) *htmlNode {
+	root := &htmlNode{tag: "#root"}
+	p := htmlParser{stack: []*htmlNode{root}}
+
+	for len(s) > 0 {
+		i := strings.IndexByte(s, '<')
+		if i < 0 {
+			p.text(s)
+			break
+		}
+		p.text(s[:i])
+		s = s[i:]
+
+		switch {
+		case strings.HasPrefix(s, "<!--"):
+			s = skipPast(s[4:], "-->")
+		case len(s) > 1 && (s[1] == '!' || s[1] == '?'):
+			s = skipPast(s, ">")
+		case len(s) > 2 && s[1] == '/' && isASCIILetter(s[2]):
+			name, rest := tagName(s[2:])
+			p.end(name)
+			s = skipPast(rest, ">")
+		case len(s) > 1 && isASCIILetter(s[1]):
+			name, rest := tagName(s[1:])
+			attrs, selfClosing, rest := tagAttrs(rest)
+			s = rest
+			if rawTextElements[name] {
+				s = skipRawText(s, name)
+				continue
+			}
+			p.start(name, attrs, selfClosing)
+		default:
+			p.text("<")
+			s = s[1:]
+		}
+	}
+	return root
+}
+
+type htmlParser struct {
+	stack []*htmlNode
+}
+
+func (p *htmlParser) top() *htmlNode {
+	return p.stack[len(p.stack)-1]
+}
+
+func (p *htmlParser) text(s string) {
+	if s == "" {
+		return
+	}
+	t := p.top()
+	if n := len(t.children); n > 0 && t.children[n-1].tag == "" {
+		t.children[n-1].text += html.UnescapeString(s)
+		return
+	}
+	t.children = append(t.children, &htmlNode{text: html.UnescapeString(s)})
+}
+
+func (p *htmlParser) start(name string, attrs map[string]string, selfClosing bool) {
+	switch {
+	case name == "td" || name == "th":
+		p.closeOpen([]string{"td", "th"}, "table")
+	case name == "tr":
+		p.closeOpen([]string{"tr"}, "table")
+	case tableSections[name]:
+		p.closeOpen([]string{"thead", "tbody", "tfoot"}, "table")
+	case name == "li":
+		p.closeOpen([]string{"li"}, "ul", "ol", "table")
+	case name == "dt" || name == "dd":
+		p.closeOpen([]string{"dt", "dd"}, "dl", "table")
+	}
+	if paragraphElements[name] || blockElements[name] {
+		p.closeOpen([]string{"p"}, "table", "td", "th", "li", "div")
+	}
+
+	n := &htmlNode{tag: name, colspan: 1}
+	if v, err := strconv.Atoi(attrs["colspan"]); err == nil && v > 1 {
+		n.colspan = min(v, 1000)
+	}
+	t := p.top()
+	t.children = append(t.children, n)
+	if !voidElements[name] && !selfClosing {
+		p.stack = append(p.stack, n)
+	}
+}
+
+func (p *htmlParser) end(name string) {
+	if name == "br" {
+		p.start("br", nil, true)
+		return
+	}
+	var boundaries []string
+	switch {
+	case name == "table":
+	case name == "tr" || name == "td" || name == "th" || tableSections[name]:
+		boundaries = []string{"table"}
+	default:
+		boundaries = []string{"table", "td", "th"}
+	}
+	p.closeOpen([]string{name}, boundaries...)
+}
+
+// closeOpen closes the innermost open element named in names, and every
+// element opened inside it, unless an element named in boundaries is
+// open inside it.
+func (p *htmlParser) closeOpen(names []string, boundaries ...string) {
+	for i := len(p.stack) - 1; i > 0; i-- {
+		tag := p.stack[i].tag
+		if slices.Contains(names, tag) {
+			p.stack = p.stack[:i]
+			return
+		}
+		if slices.Contains(boundaries, tag) {
+			return
+		}
+	}
+}
+
+// tagName reads a lower-cased tag name from the start of s.
+func tagName(s string) (string, string) {
+	i := 0
+	for i < len(s) && !isTagSpace(s[i]) && s[i] != '/' && s[i] != '>' {
+		i++
+	}
+	return strings.ToLower(s[:i]), s[i:]
+}
+
+// tagAttrs reads attributes up to and including the closing ">" of a start
+// tag.
+func tagAttrs(s string) (attrs map[string]string, selfClosing bool, rest string) {
+	attrs = make(map[string]string)
+	for {
+		s = strings.TrimLeftFunc(s, func(r rune) bool { return r < utf8.RuneSelf && isTagSpace(byte(r)) })
+		switch {
+		case s == "":
+			return attrs, false, ""
+		case s[0] == '>':
+			return attrs, false, s[1:]
+		case strings.HasPrefix(s, "/>"):
+			return attrs, true, s[2:]
+		case s[0] == '/':
+			s = s[1:]
+			continue
+		}
+
+		i := 0
+		for i < len(s) && !isTagSpace(s[i]) && s[i] != '=' && s[i] != '>' && (s[i] != '/' || i == 0) {
+			i++
+		}
+		name := strings.ToLower(s[:i])
+		s = strings.TrimLeft(s[i:], " \t\r\n\f")
+
+		var value string
+		if strings.HasPrefix(s, "=") {
+			s = strings.TrimLeft(s[1:], " \t\r\n\f")
+			if s != "" && (s[0] == '"' || s[0] == '\'') {
+				end := strings.IndexByte(s[1:], s[0])
+				if end < 0 {
+					return attrs, false, ""
+				}
+				value, s = s[1:end+1], s[end+2:]
+			} else {
+				j := 0
+				for j < len(s) && !isTagSpace(s[j]) && s[j] != '>' {
+					j++
+				}
+				value, s = s[:j], s[j:]
+			}
+		}
+		if name != "" {
+			attrs[name] = html.UnescapeString(value)
+		}
+	}
+}
+
+// skipRawText skips the content and end tag of a raw text element.
+func skipRawText(s, name string) string {
+	lower := strings.ToLower(s)
+	i := strings.Index(lower, "</"+name)
+	if i < 0 {
+		return ""
+	}
+	return skipPast(s[i:], ">")
+}
+
+// skipPast returns s after the first occurrence of sep, or "" if sep does
+// not occur.
+func skipPast(s, sep string) string {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[i+len(sep):]
+	}
+	return ""
+}
+
+func isASCIILetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isTagSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// textWriter accumulates rendered text, collapsing whitespace and
+// deferring line breaks until the next word so that no leading or
+// doubled breaks are written.
+type textWriter struct {
+	b     strings.Builder
+	space bool
+	lines int
+}
+
+// text writes s with runs of whitespace collapsed to single spaces.
+func (w *textWriter) text(s string) {
+	for s != "" {
+		i := strings.IndexFunc(s, unicode.IsSpace)
+		if i < 0 {
+			w.word(s)
+			return
+		}
+		if i > 0 {
+			w.word(s[:i])
+		}
+		w.space = true
+		_, size := utf8.DecodeRuneInString(s[i:])
+		s = s[i+size:]
+	}
+}
+
+// word writes s after any pending break or space.
+func (w *textWriter) word(s string) {
+	if w.b.Len() > 0 {
+		if w.lines > 0 {
+			w.b.WriteString(strings.Repeat("\n", w.lines))
+		} else if w.space {
+			w.b.WriteByte(' ')
+		}
+	}
+	w.space, w.lines = false, 0
+	w.b.WriteString(s)
+}
+
+// raw writes s, which may span several lines, without collapsing it.
+func (w *textWriter) raw(s string) {
+	lines := strings.Split(s, "\n")
+	w.word(lines[0])
+	for _, l := range lines[1:] {
+		w.b.WriteByte('\n')
+		w.b.WriteString(l)
+	}
+}
+
+// lineBreak ends the current line, leaving n-1 blank lines before the next
+// word.
+func (w *textWriter) lineBreak(n int) {
+	if w.b.Len() > 0 {
+		w.lines = max(w.lines, n)
+	}
+}
+
+func (w *textWriter) String() string {
+	return w.b.String()
+}
+
+type htmlRenderer struct {
+	w   textWriter
+	pre int
+}
+
+func (r *htmlRenderer) children(n *htmlNode) {
+	for _, c := range n.children {
+		r.node(c)
+	}
+}
+
+func (r *htmlRenderer) node(n *htmlNode) {
+	switch {
+	case n.tag == "":
+		if r.pre > 0 {
+			r.w.raw(strings.ReplaceAll(n.text, "\r\n", "\n"))
+		} else {
+			r.w.text(n.text)
+		}
+	case droppedElements[n.tag]:
+	case n.tag == "br":
+		if r.w.b.Len() > 0 {
+			r.w.lines = min(r.w.lines+1, 2)
+		}
+	case n.tag == "table":
+		r.w.lineBreak(2)
+		if t := renderTable(n); t != "" {
+			r.w.raw(t)
+		}
+		r.w.lineBreak(2)
+	case n.tag == "li":
+		r.w.lineBreak(1)
+		r.w.word("-")
+		r.w.space = true
+		r.children(n)
+		r.w.lineBreak(1)
+	case n.tag == "td" || n.tag == "th":
+		// A cell outside a table.
+		r.w.space = true
+		r.children(n)
+		r.w.space = true
+	case paragraphElements[n.tag]:
+		r.block(n, 2)
+	case blockElements[n.tag]:
+		r.block(n, 1)
+	default:
+		r.children(n)
+	}
+}
+
+func (r *htmlRenderer) block(n *htmlNode, lines int) {
+	if n.tag == "pre" {
+		r.pre++
+		defer func() { r.pre-- }()
+	}
+	r.w.lineBreak(lines)
+	r.children(n)
+	r.w.lineBreak(lines)
+}
+
+// tableCell is a rendered table cell.
+type tableCell struct {
+	col   int
+	span  int
+	lines []string
+}
+
+// renderTable lays out the rows of t as aligned text columns separated by
+// two spaces.
+func renderTable(t *htmlNode) string {
+	var rows [][]tableCell
+	var caption string
+	collectRows(t, &rows, &caption)
+
+	// Drop empty cells, empty rows and columns in which no cell starts.
+	used := map[int]bool{}
+	kept := rows[:0]
+	for _, row := range rows {
+		row = slices.DeleteFunc(row, func(c tableCell) bool { return len(c.lines) == 0 })
+		if len(row) == 0 {
+			continue
+		}
+		for _, c := range row {
+			used[c.col] = true
+		}
+		kept = append(kept, row)
+	}
+	rows = kept
+
+	cols := make([]int, 0, len(used))
+	for c := range used {
+		cols = append(cols, c)
+	}
+	slices.Sort(cols)
+	remap := make(map[int]int, len(cols))
+	for i, c := range cols {
+		remap[c] = i
+	}
+	for _, row := range rows {
+		for i, c := range row {
+			end := c.col + c.span
+			row[i].col = remap[c.col]
+			row[i].span = 0
+			for _, k := range cols {
+				if k >= c.col && k < end {
+					row[i].span++
+				}
+			}
+		}
+	}
+
+	var w textWriter
+	if caption != "" {
+		w.raw(caption)
+		w.lineBreak(1)
+	}
+	if len(cols) <= 1 {
+		// A layout table: render the cells as paragraphs.
+		for _, row := range rows {
+			for _, c := range row {
+				w.raw(strings.Join(c.lines, "\n"))
+				w.lineBreak(2)
+			}
+		}
+		return w.String()
+	}
+
+	const gap = 2
+	widths := make([]int, len(cols))
+	for _, span := range []bool{false, true} {
+		for _, row := range rows {
+			for _, c := range row {
+				if (c.span > 1) != span {
+					continue
+				}
+				need := cellWidth(c) - spanWidth(widths[c.col:c.col+c.span], gap)
+				if need > 0 {
+					widths[c.col+c.span-1] += need
+				}
+			}
+		}
+	}
+
+	for _, row := range rows {
+		height := 0
+		for _, c := range row {
+			height = max(height, len(c.lines))
+		}
+		for l := range height {
+			var line strings.Builder
+			col := 0
+			for _, c := range row {
+				if c.col > col {
+					line.WriteString(strings.Repeat(" ", spanWidth(widths[col:c.col], gap)+gap))
+				}
+				text := ""
+				if l < len(c.lines) {
+					text = c.lines[l]
+				}
+				width := spanWidth(widths[c.col:c.col+c.span], gap)
+				line.WriteString(text)
+				line.WriteString(strings.Repeat(" ", width-utf8.RuneCountInString(text)+gap))
+				col = c.col + c.span
+			}
+			w.raw(strings.TrimRightFunc(line.String(), unicode.IsSpace))
+			w.lineBreak(1)
+		}
+	}
+	return w.String()
+}
+
+// collectRows gathers the rows of t, without descending into nested
+// tables. Cells outside any row form a row of their own.
+func collectRows(t *htmlNode, rows *[][]tableCell, caption *string) {
+	var loose []*htmlNode
+	flush := func() {
+		if len(loose) > 0 {
+			*rows = append(*rows, renderRow(loose))
+			loose = nil
+		}
+	}
+	for _, c := range t.children {
+		switch {
+		case c.tag == "tr":
+			flush()
+			*rows = append(*rows, renderRow(c.children))
+		case tableSections[c.tag]:
+			flush()
+			collectRows(c, rows, caption)
+		case c.tag == "td" || c.tag == "th":
+			loose = append(loose, c)
+		case c.tag == "caption":
+			var r htmlRenderer
+			r.children(c)
+			*caption = r.w.String()
+		}
+	}
+	flush()
+}
+
+// renderRow renders the cells among nodes.
+func renderRow(nodes []*htmlNode) []tableCell {
+	var row []tableCell
+	col := 0
+	for _, n := range nodes {
+		if n.tag != "td" && n.tag != "th" {
+			continue
+		}
+		var r htmlRenderer
+		r.children(n)
+		c := tableCell{col: col, span: n.colspan}
+		if s := tidyText(r.w.String()); s != "" {
+			c.lines = strings.Split(s, "\n")
+		}
+		row = append(row, c)
+		col += n.colspan
+	}
+	return row
+}
+
+func cellWidth(c tableCell) int {
+	width := 0
+	for _, l := range c.lines {
+		width = max(width, utf8.RuneCountInString(l))
+	}
+	return width
+}
+
+// spanWidth returns the width of adjacent columns joined by gap spaces.
+func spanWidth(widths []int, gap int) int {
+	if len(widths) == 0 {
+		return 0
+	}
+	total := gap * (len(widths) - 1)
+	for _, w := range widths {
+		total += w
+	}
+	return total
+}
+
+// tidyText trims trailing whitespace from every line, limits runs of blank
+// lines to one and trims leading and trailing blank lines.
+func tidyText(s string) string {
+	lines := strings.Split(s, "\n")
+	out := lines[:0]
+	blank := false
+	for _, l := range lines {
+		l = strings.TrimRightFunc(l, unicode.IsSpace)
+		if l == "" {
+			blank = len(out) > 0
+			continue
+		}
+		if blank {
+			out = append(out, "")
+			blank = false
+		}
+		out = append(out, l)
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,84 @@
+package kap
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestHTMLToText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"paragraphs", "<p>Hello   <b>world</b></p><p>Second</p>", "Hello world\n\nSecond"},
+		{"line break", "Line<br>break", "Line\nbreak"},
+		{"script and style", "<script>alert(1)</script><style>p{}</style>Text", "Text"},
+		{"comment", "<!-- comment -->Visible", "Visible"},
+		{"list", "<ul><li>One</li><li>Two</li></ul>", "- One\n- Two"},
+		{"nested blocks", "<div>a<div>b</div>c</div>", "a\nb\nc"},
+		{"unclosed tags", "<p>unclosed <b>bold", "unclosed bold"},
+		{"entities", "&amp; &lt;tag&gt; &#351; &nbsp;x", "& <tag> ş x"},
+		{
+			"table",
+			"<table><tr><th>Name</th><th>Amount</th></tr><tr><td>Sermaye</td><td>1.000</td></tr></table>",
+			"Name     Amount\nSermaye  1.000",
+		},
+		{
+			"colspan",
+			"<table><tr><td colspan=2>Wide</td></tr><tr><td>a</td><td>b</td></tr></table>",
+			"Wide\na  b",
+		},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		if got := HTMLToText(tt.in); got != tt.want {
+			t.Errorf("%s: HTMLToText(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeText(t *testing.T) {
+	tests := []struct {
+		in   []byte
+		want string
+	}{
+		{[]byte("ş"), "ş"},
+		{[]byte{0x73, 0xFE}, "sş"},
+		{[]byte{0xDE, 0xFE, 0xD0, 0xF0, 0xDD, 0xFD}, "ŞşĞğİı"},
+		{[]byte("plain"), "plain"},
+	}
+	for _, tt := range tests {
+		if got := decodeText(tt.in); got != tt.want {
+			t.Errorf("decodeText(% x) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestHTMLMessage(t *testing.T) {
+	tr := base64.StdEncoding.EncodeToString([]byte("<p>Pay \xFEirketi</p>"))
+	en := base64.StdEncoding.EncodeToString([]byte("<p>Share company</p>"))
+	m := HTMLMessage{TR: &tr, EN: &en}
+
+	html, err := m.HTML(Turkish)
+	if err != nil || html != "<p>Pay şirketi</p>" {
+		t.Errorf("HTML(Turkish) = %q, %v", html, err)
+	}
+	text, err := m.Text(English)
+	if err != nil || text != "Share company" {
+		t.Errorf("Text(English) = %q, %v", text, err)
+	}
+
+	bad := "not base64!"
+	if _, err := (HTMLMessage{TR: &bad}).HTML(Turkish); err == nil {
+		t.Error("HTML of invalid base64 succeeded")
+	}
+	if text, err := (HTMLMessage{}).Text(English); err != nil || text != "" {
+		t.Errorf("Text of empty message = %q, %v", text, err)
+	}
+
+	d := &DisclosureDetail{HTMLMessages: []HTMLMessage{m, m}}
+	if text, err := d.Text(English); err != nil || text != "Share company\n\nShare company" {
+		t.Errorf("DisclosureDetail.Text = %q, %v", text, err)
+	}
+}
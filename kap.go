@@ -1,6 +1,7 @@
 package kap
 
 import (
+	"log/slog"
 	"net/http"
 	"sync"
 	"time"
@@ -16,13 +17,25 @@ const (
 
 // Client is a KAP API client. It is safe for concurrent use.
 type Client struct {
-	baseURL    string
-	apiKey     string
-	token      string
-	httpClient *http.Client
-	basicAuth  *basicAuth
+	baseURL     string
+	apiKey      string
+	httpClient  *http.Client
+	basicAuth   *basicAuth
+	tokenStore  TokenStore
+	retryPolicy *RetryPolicy
 
-	mu sync.RWMutex // protects token
+	rateLimiter      *rateLimiter
+	endpointLimiters map[string]*rateLimiter
+
+	middleware []Middleware
+	handler    Handler
+	logger     *slog.Logger
+
+	blockList *blockList
+
+	mu      sync.RWMutex // protects token and refresh
+	token   Token
+	refresh *tokenRefresh
 }
 
 // NewClient creates a new KAP API client. The apiKey is required for
@@ -39,5 +52,6 @@ func NewClient(apiKey string, opts ...Option) *Client {
 	for _, opt := range opts {
 		opt(c)
 	}
+	c.handler = c.buildHandler()
 	return c
 }
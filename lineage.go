@@ -0,0 +1,179 @@
+package kap
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// maxLineageDepth bounds the walk back to the original disclosure.
+const maxLineageDepth = 100
+
+// DefaultLineageWindow is the number of disclosure indices searched past
+// the latest known version when LineageOptions.Window is zero.
+const DefaultLineageWindow = 25000
+
+// LineageOptions configures DisclosureLineage. The zero value is usable.
+type LineageOptions struct {
+	// FileType is passed to DisclosureDetail. Empty means "html", which
+	// every disclosure supports.
+	FileType string
+
+	// Until is the last disclosure index searched for later revisions.
+	// Zero means no fixed limit; the search is then bounded by Window.
+	Until int
+
+	// Window is how many indices past the latest version found so far
+	// the search continues. Each revision found extends the search. Zero
+	// means DefaultLineageWindow; a negative value searches up to Until or
+	// the latest disclosure.
+	Window int
+}
+
+// Lineage is a disclosure with its updates, corrections and
+// cancellations.
+type Lineage struct {
+	// Versions holds the original disclosure followed by its revisions,
+	// in index order.
+	Versions []*DisclosureDetail
+
+	// Effective is the version currently in force: the latest version
+	// that no later version supersedes and no cancellation voids. It is
+	// nil if the disclosure was cancelled.
+	Effective *DisclosureDetail
+}
+
+// Original returns the disclosure the lineage starts from.
+func (l *Lineage) Original() *DisclosureDetail {
+	return l.Versions[0]
+}
+
+// Cancelled reports whether the disclosure as a whole was cancelled.
+func (l *Lineage) Cancelled() bool {
+	return l.Effective == nil
+}
+
+// IsEffective reports whether the version with the given index is the one
+// in force. Superseded, cancelled and cancelling versions are not.
+func (l *Lineage) IsEffective(index int) bool {
+	return l.Effective != nil && int(l.Effective.DisclosureIndex) == index
+}
+
+// DisclosureLineage assembles the lineage of the disclosure at index,
+// which may be the original or any of its revisions. It follows
+// RelatedDisclosureIndex back to the original, then searches the same
+// sender's later disclosures of the same class for revisions that refer
+// to any version, directly or through another revision.
+//
+// The forward search costs one DisclosureDetail request for every
+// disclosure of the same sender and class in the searched range, which by
+// default ends DefaultLineageWindow indices after the latest version
+// found. Revisions published later than that are missed; widen
+// opts.Window or set opts.Until when they matter.
+func (c *Client) DisclosureLineage(ctx context.Context, index int, opts *LineageOptions) (*Lineage, error) {
+	var o LineageOptions
+	if opts != nil {
+		o = *opts
+	}
+	if o.FileType == "" {
+		o.FileType = "html"
+	}
+	if o.Window == 0 {
+		o.Window = DefaultLineageWindow
+	}
+
+	// Walk back to the original.
+	detail, err := c.DisclosureDetail(ctx, index, o.FileType, nil)
+	if err != nil {
+		return nil, err
+	}
+	versions := map[int]*DisclosureDetail{index: detail}
+	original := detail
+	for original.DisclosureReason != DisclosureReasonNew && original.RelatedDisclosureIndex > 0 {
+		related := int(original.RelatedDisclosureIndex)
+		if _, seen := versions[related]; seen || len(versions) > maxLineageDepth {
+			return nil, fmt.Errorf("kap: disclosure %d has a cyclic or overlong revision chain", index)
+		}
+		if original, err = c.DisclosureDetail(ctx, related, o.FileType, nil); err != nil {
+			return nil, err
+		}
+		versions[related] = original
+	}
+
+	// Search forward for revisions of any known version.
+	params := &DisclosureListParams{
+		DisclosureClass: original.DisclosureClass,
+		CompanyIDs:      []ID{original.SenderID, original.BehalfSenderID},
+	}
+	latest := index
+	pager := c.DisclosurePager(int(original.DisclosureIndex)+1, o.Until, params)
+	for d, err := range pager.All(ctx) {
+		if err != nil {
+			return nil, err
+		}
+		idx := int(d.DisclosureIndex)
+		if o.Window > 0 && idx > latest+o.Window {
+			break
+		}
+		candidate, ok := versions[idx]
+		if !ok {
+			if candidate, err = c.DisclosureDetail(ctx, idx, o.FileType, nil); err != nil {
+				return nil, err
+			}
+		}
+		if _, related := versions[int(candidate.RelatedDisclosureIndex)]; related {
+			versions[idx] = candidate
+			latest = max(latest, idx)
+		}
+	}
+
+	return newLineage(versions, int(original.DisclosureIndex)), nil
+}
+
+// newLineage orders the versions reachable from the original and picks
+// the effective one. Every revision supersedes the version it refers to,
+// and a cancellation voids itself, the version it refers to and every
+// version that one superseded.
+func newLineage(versions map[int]*DisclosureDetail, original int) *Lineage {
+	l := &Lineage{}
+	indices := make([]int, 0, len(versions))
+	for idx := range versions {
+		indices = append(indices, idx)
+	}
+	slices.Sort(indices)
+
+	superseded := map[int]bool{}
+	cancelled := map[int]bool{}
+	for _, idx := range indices {
+		v := versions[idx]
+		l.Versions = append(l.Versions, v)
+		if v.DisclosureReason == DisclosureReasonNew {
+			continue
+		}
+		related := int(v.RelatedDisclosureIndex)
+		superseded[related] = true
+		if v.DisclosureReason != DisclosureReasonCancellation {
+			continue
+		}
+		cancelled[idx] = true
+		for r := related; !cancelled[r]; {
+			cancelled[r] = true
+			prev, ok := versions[r]
+			if !ok || prev.DisclosureReason == DisclosureReasonNew {
+				break
+			}
+			r = int(prev.RelatedDisclosureIndex)
+		}
+	}
+	if cancelled[original] {
+		return l
+	}
+	for i := len(l.Versions) - 1; i >= 0; i-- {
+		idx := int(l.Versions[i].DisclosureIndex)
+		if !superseded[idx] && !cancelled[idx] {
+			l.Effective = l.Versions[i]
+			break
+		}
+	}
+	return l
+}
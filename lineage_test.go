@@ -0,0 +1,162 @@
+package kap
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestNewLineage(t *testing.T) {
+	type v struct {
+		index   int
+		reason  DisclosureReason
+		related int
+	}
+	tests := []struct {
+		name      string
+		versions  []v
+		effective int // 0: cancelled
+	}{
+		{
+			name:      "original only",
+			versions:  []v{{100, DisclosureReasonNew, 0}},
+			effective: 100,
+		},
+		{
+			name:      "correction",
+			versions:  []v{{100, DisclosureReasonNew, 0}, {110, DisclosureReasonCorrection, 100}},
+			effective: 110,
+		},
+		{
+			name: "update of correction",
+			versions: []v{
+				{100, DisclosureReasonNew, 0},
+				{110, DisclosureReasonCorrection, 100},
+				{120, DisclosureReasonUpdate, 110},
+			},
+			effective: 120,
+		},
+		{
+			name: "cancelled at the end of a chain",
+			versions: []v{
+				{100, DisclosureReasonNew, 0},
+				{110, DisclosureReasonCorrection, 100},
+				{120, DisclosureReasonUpdate, 110},
+				{130, DisclosureReasonCancellation, 120},
+			},
+			effective: 0,
+		},
+		{
+			name:      "original cancelled",
+			versions:  []v{{100, DisclosureReasonNew, 0}, {110, DisclosureReasonCancellation, 100}},
+			effective: 0,
+		},
+		{
+			name: "two revisions of the original",
+			versions: []v{
+				{100, DisclosureReasonNew, 0},
+				{110, DisclosureReasonCorrection, 100},
+				{120, DisclosureReasonUpdate, 100},
+			},
+			effective: 120,
+		},
+		{
+			name: "sibling revisions of an update",
+			versions: []v{
+				{100, DisclosureReasonNew, 0},
+				{110, DisclosureReasonUpdate, 100},
+				{120, DisclosureReasonCorrection, 110},
+				{130, DisclosureReasonUpdate, 110},
+			},
+			effective: 130,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			versions := map[int]*DisclosureDetail{}
+			for _, ver := range tt.versions {
+				versions[ver.index] = &DisclosureDetail{
+					DisclosureIndex:        Index(ver.index),
+					DisclosureReason:       ver.reason,
+					RelatedDisclosureIndex: Index(ver.related),
+				}
+			}
+			l := newLineage(versions, tt.versions[0].index)
+			if len(l.Versions) != len(tt.versions) {
+				t.Fatalf("got %d versions, want %d", len(l.Versions), len(tt.versions))
+			}
+			for i, ver := range l.Versions {
+				if int(ver.DisclosureIndex) != tt.versions[i].index {
+					t.Errorf("Versions[%d] = %d, want %d", i, ver.DisclosureIndex, tt.versions[i].index)
+				}
+			}
+			if tt.effective == 0 {
+				if !l.Cancelled() {
+					t.Errorf("Effective = %d, want cancelled", l.Effective.DisclosureIndex)
+				}
+				return
+			}
+			if l.Cancelled() || int(l.Effective.DisclosureIndex) != tt.effective {
+				t.Errorf("Effective = %v, want %d", l.Effective, tt.effective)
+			}
+			for _, ver := range tt.versions {
+				if got := l.IsEffective(ver.index); got != (ver.index == tt.effective) {
+					t.Errorf("IsEffective(%d) = %v", ver.index, got)
+				}
+			}
+		})
+	}
+}
+
+// TestDisclosureLineageWindow checks that the forward search stops a
+// window past the latest version instead of paging to the last
+// disclosure.
+func TestDisclosureLineageWindow(t *testing.T) {
+	const last = 5000
+	related := map[int]int{110: 100, 150: 110}
+	var details atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/api/vyk/disclosureDetail/"):
+			details.Add(1)
+			idx, _ := strconv.Atoi(path.Base(r.URL.Path))
+			reason, rel := "NEW", 0
+			if p, ok := related[idx]; ok {
+				reason, rel = "CORR", p
+			}
+			fmt.Fprintf(w, `{"disclosureIndex":%d,"disclosureReason":%q,"relatedDisclosureIndex":%d,"disclosureClass":"ODA","senderId":"1"}`, idx, reason, rel)
+		case r.URL.Path == "/api/vyk/disclosures":
+			// Every tenth index is a disclosure by the same sender.
+			start, _ := strconv.Atoi(r.URL.Query().Get("disclosureIndex"))
+			start = (start + 9) / 10 * 10
+			var items []string
+			for i := start; i <= last && len(items) < 50; i += 10 {
+				items = append(items, fmt.Sprintf(`{"disclosureIndex":"%d","disclosureClass":"ODA","companyId":"1"}`, i))
+			}
+			fmt.Fprint(w, "["+strings.Join(items, ",")+"]")
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	l, err := c.DisclosureLineage(context.Background(), 100, &LineageOptions{Window: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(l.Versions); got != 3 {
+		t.Errorf("got %d versions, want 3", got)
+	}
+	if l.Effective == nil || l.Effective.DisclosureIndex != 150 {
+		t.Errorf("Effective = %v, want 150", l.Effective)
+	}
+	// The original, then 110 to 250: one past the window is listed but
+	// not fetched.
+	if got := details.Load(); got != 1+15 {
+		t.Errorf("fetched %d details, want %d", got, 1+15)
+	}
+}
@@ -0,0 +1,138 @@
+package kap
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redacted replaces secret values in logs and error messages.
+const redacted = "REDACTED"
+
+// secretParams lists query parameters whose values must never be logged.
+var secretParams = []string{"apiKey"}
+
+// loggingMiddleware logs every request that reaches the network. Successful
+// requests are logged once the response body is closed, so that latency
+// and byte counts cover the whole transfer.
+func loggingMiddleware(logger *slog.Logger) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*http.Response, error) {
+			start := time.Now()
+			attrs := []slog.Attr{
+				slog.String("operation", req.Operation),
+				slog.String("path", req.Path),
+				slog.String("query", redactQuery(req.Params)),
+				slog.Int("attempt", req.Attempt),
+			}
+
+			resp, err := next(ctx, req)
+			if err != nil {
+				status, code := errorStatus(err)
+				attrs = append(attrs,
+					slog.Int("status", status),
+					slog.Duration("latency", time.Since(start)),
+					slog.String("error_code", code),
+					slog.String("error", err.Error()),
+				)
+				logger.LogAttrs(ctx, slog.LevelWarn, "kap request failed", attrs...)
+				return nil, err
+			}
+
+			attrs = append(attrs, slog.Int("status", resp.StatusCode))
+			resp.Body = &loggedBody{ReadCloser: resp.Body, done: func(n int64) {
+				attrs = append(attrs,
+					slog.Duration("latency", time.Since(start)),
+					slog.Int64("bytes", n),
+				)
+				logger.LogAttrs(ctx, slog.LevelInfo, "kap request", attrs...)
+			}}
+			return resp, nil
+		}
+	}
+}
+
+// loggedBody counts the bytes read from a response body and reports the
+// total once, when the body is closed.
+type loggedBody struct {
+	io.ReadCloser
+	n    int64
+	once sync.Once
+	done func(n int64)
+}
+
+func (b *loggedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
+
+func (b *loggedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.done(b.n) })
+	return err
+}
+
+// errorStatus extracts the HTTP status and KAP error code from err, if any.
+func errorStatus(err error) (int, string) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatus, apiErr.Code
+	}
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.HTTPStatus, ""
+	}
+	return 0, ""
+}
+
+// redactQuery encodes params with secret values replaced.
+func redactQuery(params url.Values) string {
+	var q url.Values
+	for _, name := range secretParams {
+		if _, ok := params[name]; ok {
+			if q == nil {
+				q = maps.Clone(params)
+			}
+			q[name] = []string{redacted}
+		}
+	}
+	if q == nil {
+		return params.Encode()
+	}
+	return q.Encode()
+}
+
+// redactSecrets replaces the values of secret query parameters appearing
+// anywhere in s, such as in the URL of a *url.Error.
+func redactSecrets(s string) string {
+	for _, name := range secretParams {
+		prefix := name + "="
+		var b strings.Builder
+		rest := s
+		for {
+			i := strings.Index(rest, prefix)
+			if i < 0 {
+				break
+			}
+			b.WriteString(rest[:i+len(prefix)])
+			b.WriteString(redacted)
+			rest = rest[i+len(prefix):]
+			if j := strings.IndexAny(rest, "&#\"' \t\r\n"); j >= 0 {
+				rest = rest[j:]
+			} else {
+				rest = ""
+			}
+		}
+		b.WriteString(rest)
+		s = b.String()
+	}
+	return s
+}
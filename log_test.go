@@ -0,0 +1,98 @@
+package kap
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+const (
+	secretKey   = "s3cr3t-api-key"
+	secretToken = "s3cr3t-bearer-token"
+)
+
+// TestLoggerRedactsSecrets checks that neither the API key nor the token
+// appears in request logs or error messages, for successful requests, API
+// errors and transport failures.
+func TestLoggerRedactsSecrets(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	srv := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == generateTokenPath:
+			w.Write([]byte(`{"token":"` + secretToken + `"}`)) //nolint:errcheck // test server
+		case strings.HasSuffix(r.URL.Path, "/lastDisclosureIndex"):
+			w.Write([]byte(`{"lastDisclosureIndex":"1"}`)) //nolint:errcheck // test server
+		case strings.HasSuffix(r.URL.Path, "/fails"):
+			http.Error(w, "bad gateway for "+r.URL.String(), http.StatusBadGateway)
+		default:
+			// Drop the connection so the client sees a *url.Error.
+			conn, _, err := http.NewResponseController(w).Hijack()
+			if err == nil {
+				conn.Close() //nolint:errcheck // test server
+			}
+		}
+	})
+	c := NewClient(secretKey, WithBaseURL(srv), WithLogger(logger))
+	ctx := context.Background()
+
+	if _, err := c.LastDisclosureIndex(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	var errs []error
+	q := url.Values{"apiKey": {secretKey}}
+	errs = append(errs, c.get(ctx, "Fails", "/fails", q, nil))
+	errs = append(errs, c.get(ctx, "Drop", "/drop", q, nil))
+	for _, err := range errs {
+		if err == nil {
+			t.Fatal("request succeeded, want error")
+		}
+		if msg := err.Error(); strings.Contains(msg, secretKey) || !strings.Contains(msg, redacted) {
+			t.Errorf("error %q does not mask the API key", msg)
+		}
+	}
+	var urlErr *url.Error
+	if !errors.As(errs[1], &urlErr) {
+		t.Fatalf("transport failure %v is not a *url.Error", errs[1])
+	}
+	if strings.Contains(urlErr.URL, secretKey) {
+		t.Errorf("url.Error URL %q contains the API key", urlErr.URL)
+	}
+
+	out := logs.String()
+	if n := strings.Count(out, "\n"); n < 4 {
+		t.Errorf("logged %d records, want at least 4:\n%s", n, out)
+	}
+	for _, secret := range []string{secretKey, secretToken} {
+		if strings.Contains(out, secret) {
+			t.Errorf("log contains %q:\n%s", secret, out)
+		}
+	}
+}
+
+func TestRedactSecrets(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"https://host/auth/generateToken?apiKey=abc", "https://host/auth/generateToken?apiKey=REDACTED"},
+		{"GET /x?a=1&apiKey=abc&b=2: EOF", "GET /x?a=1&apiKey=REDACTED&b=2: EOF"},
+		{`Get "https://h/?apiKey=abc": dial tcp`, `Get "https://h/?apiKey=REDACTED": dial tcp`},
+		{"apiKey=a apiKey=b", "apiKey=REDACTED apiKey=REDACTED"},
+		{"no secrets here", "no secrets here"},
+	}
+	for _, tt := range tests {
+		if got := redactSecrets(tt.in); got != tt.want {
+			t.Errorf("redactSecrets(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+	if got := redactQuery(url.Values{"apiKey": {"abc"}, "x": {"1"}}); got != "apiKey=REDACTED&x=1" {
+		t.Errorf("redactQuery = %q", got)
+	}
+}
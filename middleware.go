@@ -0,0 +1,53 @@
+package kap
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// Request describes a single HTTP request made on behalf of a Client
+// method. Middleware may inspect and modify it before passing it on.
+type Request struct {
+	// Operation is the name of the Client method making the call, such as
+	// "Disclosures" or "DownloadAttachment". Token generation triggered
+	// automatically by the client uses "GenerateToken".
+	Operation string
+
+	// Path is the endpoint path relative to the base URL, including any
+	// path parameters.
+	Path string
+
+	// Params holds the query parameters.
+	Params url.Values
+
+	// Header holds the request headers, including Authorization.
+	Header http.Header
+
+	// Attempt is the 1-based attempt number when a retry policy is set.
+	Attempt int
+}
+
+// Handler performs a Request. On success it returns a response with a 2xx
+// status whose body the caller must close. Otherwise it returns an
+// *APIError, a *RequestError, or any error produced by middleware.
+type Handler func(ctx context.Context, req *Request) (*http.Response, error)
+
+// Middleware wraps a Handler to observe or alter requests and their
+// outcomes, for example for logging, metrics, header injection, or fault
+// injection.
+type Middleware func(next Handler) Handler
+
+// buildHandler chains the client's middleware around roundTrip. The first
+// middleware is the outermost. Logging, if enabled, sits closest to
+// roundTrip so it records requests as they are sent.
+func (c *Client) buildHandler() Handler {
+	h := Handler(c.roundTrip)
+	if c.logger != nil {
+		h = loggingMiddleware(c.logger)(h)
+	}
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		h = c.middleware[i](h)
+	}
+	return h
+}
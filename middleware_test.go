@@ -0,0 +1,150 @@
+package kap
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder returns middleware that appends name to calls before and after
+// passing the request on.
+func recorder(mu *sync.Mutex, calls *[]string, name string) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*http.Response, error) {
+			mu.Lock()
+			*calls = append(*calls, name+" "+req.Operation)
+			mu.Unlock()
+			resp, err := next(ctx, req)
+			mu.Lock()
+			*calls = append(*calls, name+" done")
+			mu.Unlock()
+			return resp, err
+		}
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls []string
+	)
+	c := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"lastDisclosureIndex":"7"}`)) //nolint:errcheck // test server
+	},
+		WithMiddleware(recorder(&mu, &calls, "a"), recorder(&mu, &calls, "b")),
+		WithMiddleware(recorder(&mu, &calls, "c")),
+	)
+
+	if _, err := c.LastDisclosureIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"a LastDisclosureIndex", "b LastDisclosureIndex", "c LastDisclosureIndex",
+		"c done", "b done", "a done",
+	}
+	if !slices.Equal(calls, want) {
+		t.Errorf("calls = %q, want %q", calls, want)
+	}
+}
+
+func TestMiddlewareRequest(t *testing.T) {
+	var got *http.Request
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Write([]byte(`{"lastDisclosureIndex":"7"}`)) //nolint:errcheck // test server
+	}, WithMiddleware(func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*http.Response, error) {
+			if req.Path != "/api/vyk/lastDisclosureIndex" || req.Attempt != 1 {
+				t.Errorf("request = %+v", req)
+			}
+			if req.Header.Get("Authorization") != "test-token" {
+				t.Errorf("Authorization = %q, want the bearer token", req.Header.Get("Authorization"))
+			}
+			req.Header.Set("X-Trace", "abc")
+			return next(ctx, req)
+		}
+	}))
+
+	if _, err := c.LastDisclosureIndex(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got.Header.Get("X-Trace") != "abc" {
+		t.Errorf("X-Trace = %q, want the header set by middleware", got.Header.Get("X-Trace"))
+	}
+}
+
+func TestMiddlewareSeesErrors(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		attempts []int
+		errs     []error
+	)
+	status := http.StatusServiceUnavailable
+	c := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+		if status == http.StatusBadRequest {
+			w.Write([]byte(`{"code":"ER001","message":"bad"}`)) //nolint:errcheck // test server
+		}
+	},
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2, BaseBackoff: time.Millisecond}),
+		WithMiddleware(func(next Handler) Handler {
+			return func(ctx context.Context, req *Request) (*http.Response, error) {
+				resp, err := next(ctx, req)
+				mu.Lock()
+				attempts = append(attempts, req.Attempt)
+				errs = append(errs, err)
+				mu.Unlock()
+				return resp, err
+			}
+		}),
+	)
+
+	_, err := c.LastDisclosureIndex(context.Background())
+	var reqErr *RequestError
+	if !errors.As(err, &reqErr) || reqErr.HTTPStatus != status {
+		t.Fatalf("error = %v, want a RequestError with HTTP %d", err, status)
+	}
+	if !slices.Equal(attempts, []int{1, 2}) {
+		t.Errorf("attempts = %v, want [1 2]", attempts)
+	}
+	for _, e := range errs {
+		if !errors.As(e, &reqErr) {
+			t.Errorf("middleware saw %v, want a RequestError", e)
+		}
+	}
+
+	status = http.StatusBadRequest
+	errs = nil
+	_, err = c.LastDisclosureIndex(context.Background())
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "ER001" {
+		t.Fatalf("error = %v, want APIError ER001", err)
+	}
+	if len(errs) != 1 || !errors.As(errs[0], &apiErr) {
+		t.Errorf("middleware saw %v, want one APIError", errs)
+	}
+}
+
+func TestMiddlewareShortCircuit(t *testing.T) {
+	errInjected := errors.New("injected")
+	var hit bool
+	c := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		hit = true
+		w.Write([]byte(`{"lastDisclosureIndex":"7"}`)) //nolint:errcheck // test server
+	}, WithMiddleware(func(Handler) Handler {
+		return func(context.Context, *Request) (*http.Response, error) {
+			return nil, errInjected
+		}
+	}))
+
+	if _, err := c.LastDisclosureIndex(context.Background()); !errors.Is(err, errInjected) {
+		t.Errorf("error = %v, want the middleware's error", err)
+	}
+	if hit {
+		t.Error("request reached the server despite the middleware returning early")
+	}
+}
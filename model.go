@@ -9,22 +9,28 @@ type TokenResponse struct {
 
 // Disclosure represents a single item in the disclosure list.
 type Disclosure struct {
-	DisclosureIndex       string   `json:"disclosureIndex"`
-	DisclosureType        string   `json:"disclosureType"`
-	DisclosureClass       string   `json:"disclosureClass"`
-	SubReportIDs          []string `json:"subReportIds"`
-	Title                 string   `json:"title"`
-	CompanyID             string   `json:"companyId"`
-	FundID                string   `json:"fundId,omitempty"`
-	FundCode              string   `json:"fundCode,omitempty"`
-	AcceptedDataFileTypes []string `json:"acceptedDataFileTypes"`
+	DisclosureIndex       Index           `json:"disclosureIndex"`
+	DisclosureType        DisclosureType  `json:"disclosureType"`
+	DisclosureClass       DisclosureClass `json:"disclosureClass"`
+	SubReportIDs          []SubReportID   `json:"subReportIds"`
+	Title                 string          `json:"title"`
+	CompanyID             ID              `json:"companyId"`
+	FundID                ID              `json:"fundId,omitempty"`
+	FundCode              string          `json:"fundCode,omitempty"`
+	AcceptedDataFileTypes []string        `json:"acceptedDataFileTypes"`
 }
 
 // DisclosureListParams holds optional filters for the Disclosures endpoint.
+// The single-value fields are combined with their plural counterparts; a
+// disclosure matches if it matches any of the given values of each filter.
 type DisclosureListParams struct {
-	DisclosureClass string
-	DisclosureType  string
-	CompanyID       string
+	DisclosureClass DisclosureClass
+	DisclosureType  DisclosureType
+	CompanyID       ID
+
+	DisclosureClasses []DisclosureClass
+	DisclosureTypes   []DisclosureType
+	CompanyIDs        []ID
 }
 
 // LocalizedText holds Turkish and English translations.
@@ -40,7 +46,7 @@ type AttachmentURL struct {
 }
 
 // PresentationItem holds structured presentation data. The Content field
-// varies by disclosure type, so it is kept as raw JSON.
+// is kept as raw JSON; use Decode for its contexts, units and facts.
 type PresentationItem struct {
 	ID      string          `json:"id"`
 	Content json.RawMessage `json:"content"`
@@ -67,27 +73,27 @@ type RelatedStock struct {
 
 // DisclosureDetail holds full details for a single disclosure.
 type DisclosureDetail struct {
-	DisclosureIndex        string             `json:"disclosureIndex"`
-	SenderID               string             `json:"senderId"`
+	DisclosureIndex        Index              `json:"disclosureIndex"`
+	SenderID               ID                 `json:"senderId"`
 	SenderTitle            string             `json:"senderTitle"`
 	SenderExchCodes        []string           `json:"senderExchCodes"`
-	BehalfSenderID         string             `json:"behalfSenderId,omitempty"`
+	BehalfSenderID         ID                 `json:"behalfSenderId,omitempty"`
 	BehalfSenderTitle      string             `json:"behalfSenderTitle,omitempty"`
 	BehalfSenderExchCodes  []string           `json:"behalfSenderExchCodes,omitempty"`
 	BehalfFundCode         string             `json:"behalfFundCode,omitempty"`
 	BehalfFundTitle        string             `json:"behalfFundTitle,omitempty"`
-	DisclosureReason       string             `json:"disclosureReason"`
+	DisclosureReason       DisclosureReason   `json:"disclosureReason"`
 	DisclosureDelayStatus  string             `json:"disclosureDelayStatus,omitempty"`
-	RelatedDisclosureIndex string             `json:"relatedDisclosureIndex,omitempty"`
-	DisclosureType         string             `json:"disclosureType"`
-	DisclosureClass        string             `json:"disclosureClass"`
+	RelatedDisclosureIndex Index              `json:"relatedDisclosureIndex,omitempty"`
+	DisclosureType         DisclosureType     `json:"disclosureType"`
+	DisclosureClass        DisclosureClass    `json:"disclosureClass"`
 	Subject                LocalizedText      `json:"subject"`
-	Consolidation          string             `json:"consolidation,omitempty"`
-	Year                   string             `json:"year,omitempty"`
+	Consolidation          Consolidation      `json:"consolidation,omitempty"`
+	Year                   Index              `json:"year,omitempty"`
 	Period                 *LocalizedText     `json:"period,omitempty"`
 	RelatedStocks          []RelatedStock     `json:"relatedStocks"`
 	Summary                LocalizedText      `json:"summary"`
-	Time                   string             `json:"time"`
+	Time                   Timestamp          `json:"time"`
 	Link                   string             `json:"link"`
 	AttachmentURLs         []AttachmentURL    `json:"attachmentUrls"`
 	EventType              string             `json:"eventType,omitempty"`
@@ -99,7 +105,7 @@ type DisclosureDetail struct {
 
 // LastDisclosureIndexResponse is returned by the LastDisclosureIndex endpoint.
 type LastDisclosureIndexResponse struct {
-	LastDisclosureIndex string `json:"lastDisclosureIndex"`
+	LastDisclosureIndex Index `json:"lastDisclosureIndex"`
 }
 
 // CAEventStatus holds the status of a corporate action event.
@@ -112,11 +118,11 @@ type CAEventStatus struct {
 
 // Member represents a KAP member company.
 type Member struct {
-	ID         string `json:"id"`
-	Title      string `json:"title"`
-	StockCode  string `json:"stockCode"`
-	MemberType string `json:"memberType"`
-	KFIFUrl    string `json:"kfifUrl,omitempty"`
+	ID         ID         `json:"id"`
+	Title      string     `json:"title"`
+	StockCode  string     `json:"stockCode"`
+	MemberType MemberType `json:"memberType"`
+	KFIFUrl    string     `json:"kfifUrl,omitempty"`
 }
 
 // Security holds security information for a listed company.
@@ -126,23 +132,23 @@ type Security struct {
 	BorsaKodu         string  `json:"borsaKodu"`
 	TakasKodu         string  `json:"takasKodu"`
 	TertipGroup       string  `json:"tertipGroup"`
-	Capital           float64 `json:"capital"`
-	CurrentCapital    float64 `json:"currentCapital"`
+	Capital           Decimal `json:"capital"`
+	CurrentCapital    Decimal `json:"currentCapital"`
 	GroupCode         string  `json:"groupCode"`
 	GroupCodeDesc     string  `json:"groupCodeDesc"`
-	BorsadaIslemeAcik bool    `json:"borsadaIslemeAcik"`
+	BorsadaIslemeAcik Flag    `json:"borsadaIslemeAcik"`
 }
 
 // CompanyInfo holds summary information about a company in the member
 // securities response.
 type CompanyInfo struct {
-	ID                     string  `json:"id"`
-	MemberType             string  `json:"memberType"`
-	SermayeSistemi         string  `json:"sermayeSistemi,omitempty"`
-	KayitliSermayeTavani   float64 `json:"kayitliSermayeTavani,omitempty"`
-	KstSonGecerlilikTarihi string  `json:"kstSonGecerlilikTarihi,omitempty"`
-	SirketUnvan            string  `json:"sirketUnvan,omitempty"`
-	MksMbrID               string  `json:"mksMbrId,omitempty"`
+	ID                     ID         `json:"id"`
+	MemberType             MemberType `json:"memberType"`
+	SermayeSistemi         string     `json:"sermayeSistemi,omitempty"`
+	KayitliSermayeTavani   Decimal    `json:"kayitliSermayeTavani,omitzero"`
+	KstSonGecerlilikTarihi string     `json:"kstSonGecerlilikTarihi,omitempty"`
+	SirketUnvan            string     `json:"sirketUnvan,omitempty"`
+	MksMbrID               string     `json:"mksMbrId,omitempty"`
 }
 
 // MemberSecurities pairs a company with its securities.
@@ -157,32 +163,32 @@ type DetailField struct {
 	NameTR          string          `json:"nameTr"`
 	NameEN          string          `json:"nameEn"`
 	Key             string          `json:"key"`
-	PublishDateTime *string         `json:"publishDateTime"`
+	PublishDateTime *Timestamp      `json:"publishDateTime"`
 	Value           json.RawMessage `json:"value"`
 	CodeKey         string          `json:"codeKey,omitempty"`
 }
 
 // Fund represents a fund in the fund list.
 type Fund struct {
-	FundID           int    `json:"fundId"`
-	FundName         string `json:"fundName"`
-	FundCode         string `json:"fundCode"`
-	FundType         string `json:"fundType"`
-	FundClass        string `json:"fundClass"`
-	FundExpiry       string `json:"fundExpiry"`
-	FundState        string `json:"fundState"`
-	Title            string `json:"title"`
-	UmbMemberTypes   string `json:"umbMemberTypes"`
-	FundMemberTypes  string `json:"fundMemberTypes"`
-	KAPUrl           string `json:"kapUrl"`
-	NonInactiveCount int    `json:"nonInactiveCount"`
-	FundCompanyID    string `json:"fundCompanyId"`
-	FundCompanyTitle string `json:"fundCompanyTitle"`
+	FundID           ID         `json:"fundId"`
+	FundName         string     `json:"fundName"`
+	FundCode         string     `json:"fundCode"`
+	FundType         FundType   `json:"fundType"`
+	FundClass        FundClass  `json:"fundClass"`
+	FundExpiry       FundExpiry `json:"fundExpiry"`
+	FundState        FundState  `json:"fundState"`
+	Title            string     `json:"title"`
+	UmbMemberTypes   string     `json:"umbMemberTypes"`
+	FundMemberTypes  string     `json:"fundMemberTypes"`
+	KAPUrl           string     `json:"kapUrl"`
+	NonInactiveCount int        `json:"nonInactiveCount"`
+	FundCompanyID    ID         `json:"fundCompanyId"`
+	FundCompanyTitle string     `json:"fundCompanyTitle"`
 }
 
 // FundListParams holds optional filters for the Funds endpoint.
 type FundListParams struct {
-	FundState []string
-	FundClass []string
-	FundType  []string
+	FundState []FundState
+	FundClass []FundClass
+	FundType  []FundType
 }
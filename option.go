@@ -1,6 +1,7 @@
 package kap
 
 import (
+	"log/slog"
 	"net/http"
 	"time"
 )
@@ -33,13 +34,109 @@ func WithHTTPClient(hc *http.Client) Option {
 }
 
 // WithToken sets a pre-existing bearer token, skipping the need to call
-// GenerateToken.
+// GenerateToken. The token's expiry is read from its JWT claims when
+// possible.
 func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token, _ = ParseToken(token)
+	}
+}
+
+// WithTokenValue sets a pre-existing bearer token, such as one loaded
+// from a TokenStore, keeping its IssuedAt and ExpiresAt as given.
+func WithTokenValue(token Token) Option {
 	return func(c *Client) {
 		c.token = token
 	}
 }
 
+// WithTokenStore shares bearer tokens through s. The client reuses a valid
+// stored token instead of generating one and saves every token it
+// generates, so clients pointing at the same store share one token.
+func WithTokenStore(s TokenStore) Option {
+	return func(c *Client) {
+		c.tokenStore = s
+	}
+}
+
+// WithRetryPolicy retries failed requests according to p. By default
+// requests are not retried.
+func WithRetryPolicy(p RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = &p
+	}
+}
+
+// WithRateLimit limits the client to rps requests per second on average,
+// allowing bursts of up to burst requests. Every HTTP request, including
+// retries and token generation, waits for the limiter or until its context
+// is done. A non-positive rps disables the limit.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(c *Client) {
+		if rps <= 0 {
+			c.rateLimiter = nil
+			return
+		}
+		c.rateLimiter = newRateLimiter(rps, burst)
+	}
+}
+
+// WithEndpointRateLimit adds a separate limit for requests whose path is
+// path or lies below it, such as "/api/vyk/downloadAttachment". These
+// requests wait on both this limit and the client-wide WithRateLimit. When
+// several endpoint limits match, the longest path wins. A non-positive rps
+// removes the limit for path.
+func WithEndpointRateLimit(path string, rps float64, burst int) Option {
+	return func(c *Client) {
+		if rps <= 0 {
+			delete(c.endpointLimiters, path)
+			return
+		}
+		if c.endpointLimiters == nil {
+			c.endpointLimiters = make(map[string]*rateLimiter)
+		}
+		c.endpointLimiters[path] = newRateLimiter(rps, burst)
+	}
+}
+
+// WithMiddleware adds middleware around every HTTP request the client
+// makes, including retries and automatic token generation. Middleware runs
+// in the order given, the first being the outermost; repeated calls append
+// to the chain.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(c *Client) {
+		c.middleware = append(c.middleware, mw...)
+	}
+}
+
+// WithLogger logs every HTTP request the client makes with its operation,
+// path, query, status, latency, size, and KAP error code. Successful
+// requests are logged at Info level once the response body is closed;
+// failures at Warn level. The apiKey query parameter is masked and headers
+// are never logged.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = logger
+	}
+}
+
+// WithBlockList makes DisclosureDetail and DownloadAttachment check the
+// block list, which is fetched on first use and again once it is older
+// than refresh. Blocked disclosures and attachments are refused with an
+// error wrapping ErrBlocked, and blocked attachments are removed from
+// DisclosureDetail.AttachmentURLs. If the list cannot be fetched, these
+// calls fail rather than serve unchecked content. A non-positive refresh
+// disables the check.
+func WithBlockList(refresh time.Duration) Option {
+	return func(c *Client) {
+		if refresh <= 0 {
+			c.blockList = nil
+			return
+		}
+		c.blockList = &blockList{refresh: refresh}
+	}
+}
+
 // basicAuth holds credentials for the test environment.
 type basicAuth struct {
 	Username string
@@ -0,0 +1,109 @@
+package kap
+
+import (
+	"context"
+	"iter"
+)
+
+// DisclosurePager walks the disclosure list from a start index, one page
+// of up to 50 disclosures at a time. Its cursor is the index the next page
+// starts from, so a job can checkpoint it and resume later with a new
+// pager. A DisclosurePager is not safe for concurrent use.
+type DisclosurePager struct {
+	client *Client
+	params *DisclosureListParams
+	cursor int
+	end    int
+	done   bool
+}
+
+// DisclosurePager returns a pager over disclosures with index from start up
+// to and including end, applying the filters in params. An end of zero or
+// less means there is no upper bound: the pager stops at the first empty
+// page, i.e. once it has caught up with the latest disclosure.
+func (c *Client) DisclosurePager(start, end int, params *DisclosureListParams) *DisclosurePager {
+	return &DisclosurePager{client: c, params: params, cursor: start, end: end}
+}
+
+// Cursor returns the index the next page starts from. After a page or
+// item has been consumed it is one past the highest index seen.
+func (p *DisclosurePager) Cursor() int {
+	return p.cursor
+}
+
+// Done reports whether the pager has reached the end of the range.
+func (p *DisclosurePager) Done() bool {
+	return p.done
+}
+
+// Next fetches the next page. It returns an empty page once the pager is
+// done. On error the cursor is left unchanged so the page can be retried.
+func (p *DisclosurePager) Next(ctx context.Context) ([]Disclosure, error) {
+	if p.done {
+		return nil, nil
+	}
+	if p.end > 0 && p.cursor > p.end {
+		p.done = true
+		return nil, nil
+	}
+
+	page, err := p.client.Disclosures(ctx, p.cursor, p.params)
+	if err != nil {
+		return nil, err
+	}
+	if len(page) == 0 {
+		p.done = true
+		return nil, nil
+	}
+
+	last := p.cursor - 1
+	items := page[:0]
+	for _, d := range page {
+		idx := int(d.DisclosureIndex)
+		if p.end > 0 && idx > p.end {
+			p.done = true
+			continue
+		}
+		items = append(items, d)
+		last = max(last, idx)
+	}
+	p.cursor = last + 1
+	return items, nil
+}
+
+// All returns an iterator over the remaining disclosures. The cursor
+// advances past each disclosure as it is yielded, so stopping early and
+// resuming from Cursor neither skips nor repeats items. Iteration stops
+// after the first error, which is yielded with a zero Disclosure, and when
+// ctx is done.
+func (p *DisclosurePager) All(ctx context.Context) iter.Seq2[Disclosure, error] {
+	return func(yield func(Disclosure, error) bool) {
+		for !p.done {
+			if err := ctx.Err(); err != nil {
+				yield(Disclosure{}, err)
+				return
+			}
+
+			start := p.cursor
+			page, err := p.Next(ctx)
+			if err != nil {
+				yield(Disclosure{}, err)
+				return
+			}
+
+			// Rewind so the cursor tracks the items actually yielded.
+			next := p.cursor
+			p.cursor = start
+			for i, d := range page {
+				p.cursor = max(p.cursor, int(d.DisclosureIndex)+1)
+				if !yield(d, nil) {
+					if i < len(page)-1 {
+						p.done = false
+					}
+					return
+				}
+			}
+			p.cursor = next
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package kap
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// listServer serves the disclosure list of the given indices, which must
+// be sorted, in pages of disclosurePageSize.
+func listServer(indices []int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start, _ := strconv.Atoi(r.URL.Query().Get("disclosureIndex"))
+		var items []string
+		for _, idx := range indices {
+			if idx >= start && len(items) < disclosurePageSize {
+				items = append(items, fmt.Sprintf(`{"disclosureIndex":"%d"}`, idx))
+			}
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(items, ","))
+	}
+}
+
+// sparseIndices returns n indices from 1000 with irregular gaps.
+func sparseIndices(n int) []int {
+	indices := make([]int, n)
+	idx := 1000
+	for i := range indices {
+		indices[i] = idx
+		idx += 1 + i%4
+	}
+	return indices
+}
+
+func TestDisclosurePagerResume(t *testing.T) {
+	all := sparseIndices(130)
+	c := newTestClient(t, listServer(all))
+	ctx := context.Background()
+
+	// Stop part way through each page of the range and resume from the
+	// cursor with a new pager each time.
+	var got []int
+	cursor := all[0]
+	for stops := 0; ; stops++ {
+		if stops > 20 {
+			t.Fatal("pager did not finish")
+		}
+		p := c.DisclosurePager(cursor, 0, nil)
+		n := 0
+		for d, err := range p.All(ctx) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, int(d.DisclosureIndex))
+			if n++; n == 37 {
+				break
+			}
+		}
+		cursor = p.Cursor()
+		if n < 37 {
+			break
+		}
+	}
+	if !slices.Equal(got, all) {
+		t.Errorf("resumed walk returned %d disclosures, want %d without gaps or duplicates:\n%v", len(got), len(all), got)
+	}
+}
+
+func TestDisclosurePagerNext(t *testing.T) {
+	all := sparseIndices(120)
+	c := newTestClient(t, listServer(all))
+	ctx := context.Background()
+	end := all[99]
+
+	p := c.DisclosurePager(all[0], end, nil)
+	page, err := p.Next(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != disclosurePageSize || p.Cursor() != all[49]+1 {
+		t.Fatalf("first page has %d items, cursor %d; want %d, %d", len(page), p.Cursor(), disclosurePageSize, all[49]+1)
+	}
+
+	// A new pager from the cursor continues where the first stopped.
+	got := indicesOf(page)
+	p = c.DisclosurePager(p.Cursor(), end, nil)
+	for !p.Done() {
+		page, err := p.Next(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, indicesOf(page)...)
+	}
+	if !slices.Equal(got, all[:100]) {
+		t.Errorf("walk returned %v, want the first 100 indices", got)
+	}
+	if page, err := p.Next(ctx); err != nil || len(page) != 0 {
+		t.Errorf("Next after Done = %v, %v", page, err)
+	}
+}
+
+func indicesOf(page []Disclosure) []int {
+	indices := make([]int, len(page))
+	for i, d := range page {
+		indices[i] = int(d.DisclosureIndex)
+	}
+	return indices
+}
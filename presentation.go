@@ -0,0 +1,384 @@
+package kap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Presentation is the decoded content of a PresentationItem: an XBRL-like
+// envelope of contexts and units, and the facts reported against them.
+//
+// Every member of the content other than the envelope fields becomes a
+// Fact, in document order, so concepts this package does not know about
+// are preserved.
+type Presentation struct {
+	ID               string
+	MultiDimensional bool
+	Contexts         []Context
+	Units            []Unit
+	Facts            []Fact
+}
+
+// Context is a reporting context. Facts refer to it by ID or by Key.
+type Context struct {
+	ID     string
+	Key    string
+	Period Period
+
+	// Raw is the context as sent, including any dimensions.
+	Raw json.RawMessage
+}
+
+// UnmarshalJSON decodes a context object.
+func (c *Context) UnmarshalJSON(data []byte) error {
+	var v struct {
+		ID     ID      `json:"id"`
+		Key    ID      `json:"key"`
+		Period *Period `json:"Period"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*c = Context{ID: string(v.ID), Key: string(v.Key), Raw: bytes.Clone(data)}
+	if v.Period != nil {
+		c.Period = *v.Period
+	}
+	return nil
+}
+
+// Period is the period of a Context: either an instant or a duration from
+// StartDate to EndDate. Dates are midnight in Istanbul.
+type Period struct {
+	Instant   time.Time
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+// IsInstant reports whether p is a point in time.
+func (p Period) IsInstant() bool {
+	return !p.Instant.IsZero()
+}
+
+// UnmarshalJSON decodes a period with "instant" or "startDate" and
+// "endDate" members in yyyy-MM-dd form.
+func (p *Period) UnmarshalJSON(data []byte) error {
+	var v struct {
+		Instant   string `json:"instant"`
+		StartDate string `json:"startDate"`
+		EndDate   string `json:"endDate"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	var err error
+	*p = Period{}
+	if p.Instant, err = parseDate(v.Instant); err != nil {
+		return err
+	}
+	if p.StartDate, err = parseDate(v.StartDate); err != nil {
+		return err
+	}
+	p.EndDate, err = parseDate(v.EndDate)
+	return err
+}
+
+// parseDate parses a yyyy-MM-dd date in Istanbul. "" yields the zero time.
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, strings.TrimSpace(s), Istanbul)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("kap: invalid date %q", s)
+	}
+	return t, nil
+}
+
+// Unit is a unit of measure, such as a currency. Ratios have a Numerator
+// and Denominator instead of a Measure.
+type Unit struct {
+	ID          string
+	Measure     string
+	Numerator   string
+	Denominator string
+
+	// Raw is the unit as sent. Measures that are not plain strings are
+	// only available here.
+	Raw json.RawMessage
+}
+
+// UnmarshalJSON decodes a unit object.
+func (u *Unit) UnmarshalJSON(data []byte) error {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	text := func(key string) string {
+		s, _, _ := flexText(obj[key])
+		return s
+	}
+	*u = Unit{
+		ID:          text("id"),
+		Measure:     text("measure"),
+		Numerator:   text("numerator"),
+		Denominator: text("denominator"),
+		Raw:         bytes.Clone(data),
+	}
+	return nil
+}
+
+// Fact is a reported value of a concept.
+type Fact struct {
+	// Concept is the member name under which the fact was reported, such
+	// as "ifrs-full_Revenue".
+	Concept    string
+	ContextRef string
+	UnitRef    string
+	Decimals   string
+
+	// Value is the text of the value, or "" if the fact has no scalar
+	// value.
+	Value string
+
+	// Raw is the fact as sent. For facts of unrecognized shape it is the
+	// only representation.
+	Raw json.RawMessage
+}
+
+// Decimal returns the value of f as a Decimal. Fact values are in XBRL
+// syntax, where "." is always the decimal point, so "1.250" is 1.25; the
+// Turkish notation accepted by ParseDecimal is not.
+func (f Fact) Decimal() (Decimal, error) {
+	v := strings.TrimSpace(f.Value)
+	return parseDecimalText(v, v)
+}
+
+// Decode decodes the content of p. The ID of p is used if the content has
+// none.
+func (p PresentationItem) Decode() (*Presentation, error) {
+	var pres Presentation
+	if err := json.Unmarshal(p.Content, &pres); err != nil {
+		return nil, fmt.Errorf("kap: decoding presentation %s: %w", p.ID, err)
+	}
+	if pres.ID == "" {
+		pres.ID = p.ID
+	}
+	return &pres, nil
+}
+
+// Presentations decodes every item of d.Presentation.
+func (d *DisclosureDetail) Presentations() ([]*Presentation, error) {
+	presentations := make([]*Presentation, 0, len(d.Presentation))
+	for _, item := range d.Presentation {
+		p, err := item.Decode()
+		if err != nil {
+			return nil, err
+		}
+		presentations = append(presentations, p)
+	}
+	return presentations, nil
+}
+
+// Context returns the context whose ID or Key is ref.
+func (p *Presentation) Context(ref string) (Context, bool) {
+	for _, c := range p.Contexts {
+		if c.ID == ref || c.Key == ref {
+			return c, true
+		}
+	}
+	return Context{}, false
+}
+
+// Unit returns the unit with the given ID.
+func (p *Presentation) Unit(id string) (Unit, bool) {
+	for _, u := range p.Units {
+		if u.ID == id {
+			return u, true
+		}
+	}
+	return Unit{}, false
+}
+
+// FactsOf returns the facts reported for concept, in document order.
+func (p *Presentation) FactsOf(concept string) []Fact {
+	var facts []Fact
+	for _, f := range p.Facts {
+		if f.Concept == concept {
+			facts = append(facts, f)
+		}
+	}
+	return facts
+}
+
+// Fact returns the fact reported for concept in the context whose ID or
+// Key is contextRef.
+func (p *Presentation) Fact(concept, contextRef string) (Fact, bool) {
+	c, ok := p.Context(contextRef)
+	for _, f := range p.Facts {
+		if f.Concept != concept {
+			continue
+		}
+		if f.ContextRef == contextRef || ok && (f.ContextRef == c.ID || f.ContextRef == c.Key) {
+			return f, true
+		}
+	}
+	return Fact{}, false
+}
+
+// Concepts returns the distinct concepts of p's facts, in document order.
+func (p *Presentation) Concepts() []string {
+	var concepts []string
+	seen := map[string]bool{}
+	for _, f := range p.Facts {
+		if !seen[f.Concept] {
+			seen[f.Concept] = true
+			concepts = append(concepts, f.Concept)
+		}
+	}
+	return concepts
+}
+
+// UnmarshalJSON decodes presentation content.
+func (p *Presentation) UnmarshalJSON(data []byte) error {
+	members, err := objectMembers(data)
+	if err != nil {
+		return err
+	}
+
+	*p = Presentation{}
+	for _, m := range members {
+		switch m.name {
+		case "id":
+			var id ID
+			if err := json.Unmarshal(m.value, &id); err != nil {
+				return fmt.Errorf("kap: invalid presentation id: %w", err)
+			}
+			p.ID = string(id)
+		case "isMultiDimensional":
+			var f Flag
+			if err := json.Unmarshal(m.value, &f); err != nil {
+				return err
+			}
+			p.MultiDimensional = bool(f)
+		case "ContextList":
+			if p.Contexts, err = decodeList[Context](m.value, "Context"); err != nil {
+				return fmt.Errorf("kap: invalid ContextList: %w", err)
+			}
+		case "UnitList":
+			if p.Units, err = decodeList[Unit](m.value, "Unit"); err != nil {
+				return fmt.Errorf("kap: invalid UnitList: %w", err)
+			}
+		default:
+			p.Facts = appendFacts(p.Facts, m.name, m.value)
+		}
+	}
+	return nil
+}
+
+// factValueKeys are the members that may hold the value of a fact object.
+var factValueKeys = []string{"value", "#text", "content", "text", "_"}
+
+// appendFacts appends the facts of concept in raw: one for a scalar or
+// object, one per element for an array.
+func appendFacts(facts []Fact, concept string, raw json.RawMessage) []Fact {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '[' {
+		var elems []json.RawMessage
+		if json.Unmarshal(raw, &elems) == nil {
+			for _, e := range elems {
+				facts = append(facts, decodeFact(concept, e))
+			}
+			return facts
+		}
+	}
+	return append(facts, decodeFact(concept, raw))
+}
+
+// decodeFact decodes a scalar or an object with contextRef, unitRef,
+// decimals and value members. Anything else is kept only as Raw.
+func decodeFact(concept string, raw json.RawMessage) Fact {
+	f := Fact{Concept: concept, Raw: raw}
+	if len(raw) == 0 || raw[0] != '{' {
+		f.Value, _, _ = flexText(raw)
+		return f
+	}
+
+	var obj map[string]json.RawMessage
+	if json.Unmarshal(raw, &obj) != nil {
+		return f
+	}
+	text := func(key string) string {
+		s, _, _ := flexText(obj[key])
+		return s
+	}
+	f.ContextRef = text("contextRef")
+	f.UnitRef = text("unitRef")
+	f.Decimals = text("decimals")
+	for _, k := range factValueKeys {
+		if v, ok := obj[k]; ok {
+			f.Value, _, _ = flexText(v)
+			break
+		}
+	}
+	return f
+}
+
+// decodeList decodes a list wrapper such as {"Context": [...]}, whose
+// member may be a single object or an array of them.
+func decodeList[T any](data []byte, member string) ([]T, error) {
+	var wrapper map[string]json.RawMessage
+	if err := json.Unmarshal(data, &wrapper); err != nil {
+		return nil, err
+	}
+	raw := bytes.TrimSpace(wrapper[member])
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	elems := []json.RawMessage{raw}
+	if raw[0] == '[' {
+		if err := json.Unmarshal(raw, &elems); err != nil {
+			return nil, err
+		}
+	}
+	list := make([]T, 0, len(elems))
+	for _, e := range elems {
+		var v T
+		if err := json.Unmarshal(e, &v); err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}
+
+// objectMember is a member of a JSON object.
+type objectMember struct {
+	name  string
+	value json.RawMessage
+}
+
+// objectMembers returns the members of a JSON object in document order.
+func objectMembers(data []byte) ([]objectMember, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, fmt.Errorf("kap: expected a JSON object, got %.40s", data)
+	}
+	var members []objectMember
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		name, _ := tok.(string)
+		members = append(members, objectMember{name: name, value: value})
+	}
+	return members, nil
+}
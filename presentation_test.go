@@ -0,0 +1,54 @@
+package kap
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestFactDecimal(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"1.250", "1.250"},
+		{"123.456", "123.456"},
+		{"-0.001", "-0.001"},
+		{"1250000", "1250000"},
+		{" 42.5 ", "42.5"},
+		{"1.5E3", "1500"},
+	}
+	for _, tt := range tests {
+		d, err := Fact{Value: tt.value}.Decimal()
+		if err != nil || d.String() != tt.want {
+			t.Errorf("Fact{Value: %q}.Decimal() = %s, %v; want %s", tt.value, d, err, tt.want)
+		}
+	}
+	for _, value := range []string{"", "1,5", "1.250.000", "n/a"} {
+		if d, err := (Fact{Value: value}).Decimal(); err == nil {
+			t.Errorf("Fact{Value: %q}.Decimal() = %s, want error", value, d)
+		}
+	}
+}
+
+func TestPresentationItemDecode(t *testing.T) {
+	item := PresentationItem{ID: "oda-1", Content: json.RawMessage(`{
+		"ContextList": {"Context": {"id": "c1", "key": "CURR", "Period": {"instant": "2023-09-30"}}},
+		"UnitList": {"Unit": [{"id": "TRY", "measure": "iso4217:TRY"}]},
+		"ifrs-full_BasicEarningsLossPerShare": {"contextRef": "c1", "unitRef": "TRY", "decimals": "3", "value": "1.250"},
+		"kap_Note": "Açıklama"
+	}`)}
+	p, err := item.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.ID != "oda-1" || len(p.Contexts) != 1 || len(p.Units) != 1 || len(p.Facts) != 2 {
+		t.Fatalf("Decode = %+v", p)
+	}
+	f, ok := p.Fact("ifrs-full_BasicEarningsLossPerShare", "CURR")
+	if !ok || f.UnitRef != "TRY" || f.Decimals != "3" {
+		t.Fatalf("Fact = %+v, %v", f, ok)
+	}
+	if d, err := f.Decimal(); err != nil || d.String() != "1.250" {
+		t.Errorf("Decimal() = %s, %v; want 1.250", d, err)
+	}
+}
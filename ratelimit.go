@@ -0,0 +1,97 @@
+package kap
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket that refills at rate tokens per second up
+// to burst tokens. Callers reserve a token up front and sleep until it is
+// due, so waiting callers are served in arrival order.
+type rateLimiter struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex // protects tokens and last
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter creates a full bucket. A burst below 1 is treated as 1.
+func newRateLimiter(rps float64, burst int) *rateLimiter {
+	b := float64(max(burst, 1))
+	return &rateLimiter{rate: rps, burst: b, tokens: b, last: time.Now()}
+}
+
+// wait blocks until a token is available or ctx is done. If ctx would
+// expire before the token is due, it returns immediately.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+	l.tokens--
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(now.Add(delay)) {
+		l.cancel()
+		return context.DeadlineExceeded
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.cancel()
+		return ctx.Err()
+	}
+}
+
+// cancel returns a reserved token that will not be used.
+func (l *rateLimiter) cancel() {
+	l.mu.Lock()
+	l.tokens = min(l.burst, l.tokens+1)
+	l.mu.Unlock()
+}
+
+// waitRateLimit waits on the client-wide limiter and on the limiter for the
+// endpoint matching path, if any.
+func (c *Client) waitRateLimit(ctx context.Context, path string) error {
+	if c.rateLimiter != nil {
+		if err := c.rateLimiter.wait(ctx); err != nil {
+			return err
+		}
+	}
+	if l := c.endpointLimiter(path); l != nil {
+		return l.wait(ctx)
+	}
+	return nil
+}
+
+// endpointLimiter returns the limiter registered for the longest endpoint
+// prefix matching path. A prefix matches the path itself and any path
+// below it, so "/api/vyk/downloadAttachment" covers every attachment ID.
+func (c *Client) endpointLimiter(path string) *rateLimiter {
+	var best string
+	var limiter *rateLimiter
+	for prefix, l := range c.endpointLimiters {
+		if path != prefix && !strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+			continue
+		}
+		if len(prefix) > len(best) {
+			best, limiter = prefix, l
+		}
+	}
+	return limiter
+}
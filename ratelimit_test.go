@@ -0,0 +1,107 @@
+package kap
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterBurst(t *testing.T) {
+	l := newRateLimiter(50, 3)
+	ctx := context.Background()
+
+	start := time.Now()
+	for range 3 {
+		if err := l.wait(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d > 10*time.Millisecond {
+		t.Errorf("burst of 3 took %v, want no wait", d)
+	}
+	if err := l.wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 15*time.Millisecond {
+		t.Errorf("fourth request after %v, want about 20ms", d)
+	}
+}
+
+func TestRateLimiterDeadline(t *testing.T) {
+	l := newRateLimiter(1, 1)
+	if err := l.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The next token is a second away, past the deadline, so wait gives
+	// up at once and returns its reservation.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := l.wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("wait error = %v, want DeadlineExceeded", err)
+	}
+	if d := time.Since(start); d > 20*time.Millisecond {
+		t.Errorf("wait took %v, want an immediate return", d)
+	}
+	l.mu.Lock()
+	tokens := l.tokens
+	l.mu.Unlock()
+	if tokens < -0.1 {
+		t.Errorf("tokens = %v after a cancelled wait, want the reservation returned", tokens)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.wait(cancelled); !errors.Is(err, context.Canceled) {
+		t.Errorf("wait with cancelled context error = %v", err)
+	}
+}
+
+func TestEndpointLimiter(t *testing.T) {
+	c := NewClient("key",
+		WithEndpointRateLimit("/api/vyk/downloadAttachment", 1, 1),
+		WithEndpointRateLimit("/api/vyk/disclosure", 1, 1),
+		WithEndpointRateLimit("/api/vyk/disclosureDetail/", 1, 1),
+	)
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/api/vyk/downloadAttachment/abc", "/api/vyk/downloadAttachment"},
+		{"/api/vyk/downloadAttachment", "/api/vyk/downloadAttachment"},
+		{"/api/vyk/disclosureDetail/5", "/api/vyk/disclosureDetail/"},
+		{"/api/vyk/disclosures", ""},
+		{"/api/vyk/downloadAttachments", ""},
+	}
+	for _, tt := range tests {
+		got := c.endpointLimiter(tt.path)
+		if want := c.endpointLimiters[tt.want]; got != want {
+			t.Errorf("endpointLimiter(%s) is not the limiter for %q", tt.path, tt.want)
+		}
+	}
+
+	c = NewClient("key", WithEndpointRateLimit("/x", 1, 1), WithEndpointRateLimit("/x", 0, 0))
+	if c.endpointLimiter("/x") != nil {
+		t.Error("non-positive rate did not remove the limit")
+	}
+}
+
+func TestClientRateLimit(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"lastDisclosureIndex":"1"}`)) //nolint:errcheck // test server
+	}, WithRateLimit(20, 1))
+
+	start := time.Now()
+	for range 5 {
+		if _, err := c.LastDisclosureIndex(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// One request is free; the other four wait 50ms each.
+	if d := time.Since(start); d < 180*time.Millisecond {
+		t.Errorf("5 requests at 20 rps took %v, want at least 200ms", d)
+	}
+}
@@ -0,0 +1,155 @@
+package kap
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultRetryBaseBackoff is the delay before the first retry when
+	// RetryPolicy.BaseBackoff is zero.
+	DefaultRetryBaseBackoff = 500 * time.Millisecond
+
+	// DefaultRetryMaxBackoff is the upper bound on the delay between
+	// attempts when RetryPolicy.MaxBackoff is zero.
+	DefaultRetryMaxBackoff = 30 * time.Second
+)
+
+// RetryPolicy controls how failed requests are retried. Delays grow
+// exponentially from BaseBackoff up to MaxBackoff. A Retry-After header on
+// the failed response overrides the computed delay when it is longer.
+// Retries stop early when the context is done or its deadline would pass
+// before the next attempt.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	// Values below 2 disable retries.
+	MaxAttempts int
+
+	// BaseBackoff is the delay before the first retry. It doubles on each
+	// subsequent retry. Zero means DefaultRetryBaseBackoff.
+	BaseBackoff time.Duration
+
+	// MaxBackoff caps the computed delay. Zero means
+	// DefaultRetryMaxBackoff.
+	MaxBackoff time.Duration
+
+	// Jitter is the fraction, between 0 and 1, of each delay that is
+	// randomized to spread out retries from concurrent callers.
+	Jitter float64
+
+	// Retryable reports whether a failed request should be retried. Nil
+	// means DefaultRetryable.
+	Retryable func(error) bool
+}
+
+// DefaultRetryable reports whether err is a transient failure: a timeout,
+// a reset or refused connection, or an HTTP 429, 502, 503 or 504 response.
+// Permission errors (ER001–ER003) and context cancellation are never
+// retried.
+func DefaultRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, ErrNoPermission) || errors.Is(err, ErrUnauthorized) || errors.Is(err, ErrIPRestricted) {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return retryableStatus(apiErr.HTTPStatus)
+	}
+
+	var reqErr *RequestError
+	if !errors.As(err, &reqErr) {
+		return false
+	}
+	if reqErr.HTTPStatus != 0 {
+		return retryableStatus(reqErr.HTTPStatus)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return isConnError(err)
+}
+
+// retryableStatus reports whether an HTTP status code indicates a
+// transient failure.
+func retryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// next reports whether the request that failed with err on the given
+// attempt should be retried, and how long to wait first. A nil policy
+// never retries.
+func (p *RetryPolicy) next(ctx context.Context, attempt int, retryAfter time.Duration, err error) (time.Duration, bool) {
+	if p == nil || attempt >= p.MaxAttempts || ctx.Err() != nil {
+		return 0, false
+	}
+
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = DefaultRetryable
+	}
+	if !retryable(err) {
+		return 0, false
+	}
+
+	wait := p.backoff(attempt)
+	if retryAfter > wait {
+		wait = retryAfter
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+		return 0, false
+	}
+	return wait, true
+}
+
+// backoff returns the jittered delay before the retry following attempt.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	base, maxBackoff := p.BaseBackoff, p.MaxBackoff
+	if base <= 0 {
+		base = DefaultRetryBaseBackoff
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultRetryMaxBackoff
+	}
+
+	d := maxBackoff
+	if shift := attempt - 1; shift < 32 && base<<shift > 0 && base<<shift < maxBackoff {
+		d = base << shift
+	}
+
+	if p.Jitter > 0 {
+		j := min(p.Jitter, 1)
+		d -= time.Duration(j * rand.Float64() * float64(d)) //nolint:gosec // jitter does not need a secure source
+	}
+	return d
+}
+
+// parseRetryAfter returns the delay requested by a Retry-After header,
+// given either as seconds or as an HTTP date. It returns zero if the header
+// is missing or invalid.
+func parseRetryAfter(h http.Header, now time.Time) time.Duration {
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
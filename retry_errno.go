@@ -0,0 +1,13 @@
+//go:build !plan9
+
+package kap
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isConnError reports whether err is a reset or refused connection.
+func isConnError(err error) bool {
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED)
+}
@@ -0,0 +1,10 @@
+package kap
+
+import "strings"
+
+// isConnError reports whether err is a reset or refused connection. Plan 9
+// reports these as error strings rather than errno values.
+func isConnError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "connection reset") || strings.Contains(msg, "connection refused")
+}
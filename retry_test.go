@@ -0,0 +1,36 @@
+//go:build !plan9
+
+package kap
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestDefaultRetryable(t *testing.T) {
+	reqErr := func(err error) error { return &RequestError{Method: "GET", Path: "/", Err: err} }
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"reset", reqErr(&net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}), true},
+		{"refused", reqErr(&net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}), true},
+		{"timeout", reqErr(os.ErrDeadlineExceeded), true},
+		{"503", &RequestError{HTTPStatus: 503, Err: errors.New("unavailable")}, true},
+		{"429 API error", &APIError{Code: "X", HTTPStatus: 429}, true},
+		{"404", &RequestError{HTTPStatus: 404, Err: errors.New("not found")}, false},
+		{"no permission", &APIError{Code: "ER001", HTTPStatus: 503}, false},
+		{"canceled", reqErr(context.Canceled), false},
+		{"plain", errors.New("boom"), false},
+	}
+	for _, tt := range tests {
+		if got := DefaultRetryable(tt.err); got != tt.want {
+			t.Errorf("%s: DefaultRetryable(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package kap
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// SubReportID identifies a sub-report of a disclosure, such as
+// "oda-34000_Unconsolidated-Operating-Review". It consists of a report
+// code and, after an underscore, the report name in English.
+type SubReportID string
+
+// subReportLabels maps report codes to their names. It is not a complete
+// catalog: it holds only the codes listed in the API reference.
+var subReportLabels = map[string]label{
+	"oda-22300": {"İzahname Özeti", "Prospectus Summary"},
+	"oda-34000": {"Konsolide Olmayan Faaliyet Raporu", "Unconsolidated Operating Review"},
+}
+
+// Code returns the report code of id, the part before the first
+// underscore, such as "oda-34000".
+func (id SubReportID) Code() string {
+	code, _, _ := strings.Cut(string(id), "_")
+	return code
+}
+
+// IsValid reports whether id is well formed: a code of letters, "-" and
+// digits, such as "oda-34000", optionally followed by "_" and a name of
+// letters, digits and "-". It does not check that the code is known.
+func (id SubReportID) IsValid() bool {
+	code, name, hasName := strings.Cut(string(id), "_")
+	prefix, number, ok := strings.Cut(code, "-")
+	if !ok || prefix == "" || number == "" || !allDigits(number) ||
+		strings.TrimFunc(prefix, isLetterRune) != "" {
+		return false
+	}
+	if !hasName {
+		return true
+	}
+	return name != "" && strings.TrimFunc(name, func(r rune) bool {
+		return isLetterRune(r) || r == '-' || (r >= '0' && r <= '9')
+	}) == ""
+}
+
+// isLetterRune reports whether r is an ASCII letter.
+func isLetterRune(r rune) bool {
+	return r < utf8.RuneSelf && isASCIILetter(byte(r))
+}
+
+// Description returns the name of the report in lang. Names are known for
+// only a few codes; for the others Description returns the English name
+// embedded in id, with hyphens as spaces, or "" if there is none.
+func (id SubReportID) Description(lang Language) string {
+	if l, ok := subReportLabels[id.Code()]; ok {
+		return l.text(lang)
+	}
+	_, name, _ := strings.Cut(string(id), "_")
+	return strings.ReplaceAll(name, "-", " ")
+}
+
+// validSubReports returns ids without duplicates, or an error naming the
+// first malformed ID.
+func validSubReports(ids []SubReportID) ([]SubReportID, error) {
+	list := make([]SubReportID, 0, len(ids))
+	for _, id := range ids {
+		if !id.IsValid() {
+			return nil, fmt.Errorf("kap: invalid sub-report ID %q", id)
+		}
+		list = appendUnique(list, id)
+	}
+	return list, nil
+}
+
+// mergeSubReport adds the presentation, flat data and messages of other,
+// a detail of another sub-report of the same disclosure, to d.
+func mergeSubReport(d, other *DisclosureDetail) {
+	for _, p := range other.Presentation {
+		if !slices.ContainsFunc(d.Presentation, func(q PresentationItem) bool { return q.ID == p.ID }) {
+			d.Presentation = append(d.Presentation, p)
+		}
+	}
+	for _, f := range other.FlatData {
+		if !slices.ContainsFunc(d.FlatData, func(g FlatDataItem) bool { return g.ID == f.ID }) {
+			d.FlatData = append(d.FlatData, f)
+		}
+	}
+	for _, m := range other.HTMLMessages {
+		if !slices.ContainsFunc(d.HTMLMessages, func(n HTMLMessage) bool { return n.ID == m.ID }) {
+			d.HTMLMessages = append(d.HTMLMessages, m)
+		}
+	}
+}
+
+// CheckSubReports returns an error naming the first of ids that is not
+// among d.SubReportIDs.
+func (d Disclosure) CheckSubReports(ids []SubReportID) error {
+	for _, id := range ids {
+		if !slices.Contains(d.SubReportIDs, id) {
+			return fmt.Errorf("kap: disclosure %d has no sub-report %q", int(d.DisclosureIndex), id)
+		}
+	}
+	return nil
+}
+
+// DisclosureSubReports fetches the detail of d limited to the given
+// sub-reports, after checking that d lists them. Use it with disclosures
+// from the Disclosures list; DisclosureDetail takes sub-report IDs without
+// checking them.
+func (c *Client) DisclosureSubReports(ctx context.Context, d Disclosure, fileType string, ids ...SubReportID) (*DisclosureDetail, error) {
+	if err := d.CheckSubReports(ids); err != nil {
+		return nil, err
+	}
+	return c.DisclosureDetail(ctx, int(d.DisclosureIndex), fileType, ids)
+}
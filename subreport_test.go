@@ -0,0 +1,101 @@
+package kap
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestSubReportIDIsValid(t *testing.T) {
+	tests := []struct {
+		id   SubReportID
+		want bool
+	}{
+		{"oda-34000_Unconsolidated-Operating-Review", true},
+		{"oda-22300_Prospectus-Summary", true},
+		{"oda-12345_Some-Report-2", true},
+		{"fr-100", true},
+		{"", false},
+		{"oda", false},
+		{"oda-", false},
+		{"-34000", false},
+		{"oda-34x00", false},
+		{"oda-34000_", false},
+		{"oda-34000_a,b", false},
+		{"oda-34000_a b", false},
+		{"oda-34000_Rapor-ş", false},
+	}
+	for _, tt := range tests {
+		if got := tt.id.IsValid(); got != tt.want {
+			t.Errorf("SubReportID(%q).IsValid() = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestSubReportIDDescription(t *testing.T) {
+	tests := []struct {
+		id   SubReportID
+		lang Language
+		want string
+	}{
+		{"oda-34000_Unconsolidated-Operating-Review", English, "Unconsolidated Operating Review"},
+		{"oda-22300_Prospectus-Summary", Turkish, "İzahname Özeti"},
+		{"oda-99999_Some-Other-Report", English, "Some Other Report"},
+		{"oda-99999", English, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.id.Description(tt.lang); got != tt.want {
+			t.Errorf("SubReportID(%q).Description(%s) = %q, want %q", tt.id, tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestDisclosureDetailSubReports(t *testing.T) {
+	var mu sync.Mutex
+	var requested []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		mu.Lock()
+		requested = append(requested, q["subReportList"]...)
+		mu.Unlock()
+		id := q.Get("subReportList")
+		fmt.Fprintf(w, `{"disclosureIndex":1,"presentation":[{"id":%q,"content":{}}],"htmlMessages":[{"id":"m"}]}`, id)
+	})
+
+	ids := []SubReportID{"oda-34000_Unconsolidated-Operating-Review", "oda-22300_Prospectus-Summary", "oda-34000_Unconsolidated-Operating-Review"}
+	d, err := c.DisclosureDetail(context.Background(), 1, "data", ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{string(ids[0]), string(ids[1])}
+	if !slices.Equal(requested, want) {
+		t.Errorf("requested sub-reports %q, want %q", requested, want)
+	}
+	var got []string
+	for _, p := range d.Presentation {
+		got = append(got, p.ID)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("presentation IDs %q, want %q", got, want)
+	}
+	if len(d.HTMLMessages) != 1 {
+		t.Errorf("got %d messages, want 1", len(d.HTMLMessages))
+	}
+
+	if _, err := c.DisclosureDetail(context.Background(), 1, "data", []SubReportID{"a,b"}); err == nil {
+		t.Error("malformed sub-report ID accepted")
+	}
+}
+
+func TestCheckSubReports(t *testing.T) {
+	d := Disclosure{DisclosureIndex: 7, SubReportIDs: []SubReportID{"oda-22300_Prospectus-Summary"}}
+	if err := d.CheckSubReports([]SubReportID{"oda-22300_Prospectus-Summary"}); err != nil {
+		t.Errorf("listed sub-report rejected: %v", err)
+	}
+	if err := d.CheckSubReports([]SubReportID{"oda-34000_Unconsolidated-Operating-Review"}); err == nil {
+		t.Error("unlisted sub-report accepted")
+	}
+}
@@ -0,0 +1,23 @@
+package kap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestClient returns a client for a test server running handler, with
+// a static token and no retries.
+func newTestClient(t *testing.T, handler http.HandlerFunc, opts ...Option) *Client {
+	t.Helper()
+	opts = append([]Option{WithBaseURL(newServer(t, handler)), WithToken("test-token")}, opts...)
+	return NewClient("test-key", opts...)
+}
+
+// newServer starts a test server running handler and returns its URL.
+func newServer(t *testing.T, handler http.HandlerFunc) string {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
@@ -0,0 +1,123 @@
+package kap
+
+import (
+	_ "embed" // for the embedded Europe/Istanbul zone
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Timestamp layouts used by the KAP API.
+const (
+	// TimestampLayout is the layout of DisclosureDetail.Time
+	// (dd.MM.yyyy HH:mm:ss).
+	TimestampLayout = "02.01.2006 15:04:05"
+
+	// SlashTimestampLayout is the layout of DetailField.PublishDateTime
+	// (dd/MM/yyyy HH:mm:ss).
+	SlashTimestampLayout = "02/01/2006 15:04:05"
+)
+
+//go:embed tzdata/Europe/Istanbul
+var istanbulTZData []byte
+
+// Istanbul is the Europe/Istanbul time zone in which KAP publishes
+// timestamps. It is loaded from embedded zone data, so it does not depend
+// on the host's zoneinfo.
+var Istanbul = mustLoadIstanbul()
+
+func mustLoadIstanbul() *time.Location {
+	loc, err := time.LoadLocationFromTZData("Europe/Istanbul", istanbulTZData)
+	if err != nil {
+		panic("kap: loading embedded Europe/Istanbul zone: " + err.Error())
+	}
+	return loc
+}
+
+// Timestamp is a KAP timestamp. The API sends local Istanbul times without
+// a zone, as "dd.MM.yyyy HH:mm:ss" or "dd/MM/yyyy HH:mm:ss"; Timestamp
+// parses either into a time.Time in the Istanbul location and marshals
+// back to the same text it was parsed from, as JSON and as text.
+//
+// Timestamps record the layout they were parsed from, so == can report
+// the same instant as different; compare them with Equal.
+type Timestamp struct {
+	time.Time
+
+	layout string
+}
+
+// ParseTimestamp parses s in either KAP timestamp layout.
+func ParseTimestamp(s string) (Timestamp, error) {
+	for _, layout := range []string{TimestampLayout, SlashTimestampLayout} {
+		if t, err := time.ParseInLocation(layout, s, Istanbul); err == nil {
+			return Timestamp{Time: t, layout: layout}, nil
+		}
+	}
+	return Timestamp{}, fmt.Errorf("kap: invalid timestamp %q", s)
+}
+
+// String formats t in the layout it was parsed from, or TimestampLayout.
+// The zero Timestamp formats as "".
+func (t Timestamp) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	layout := t.layout
+	if layout == "" {
+		layout = TimestampLayout
+	}
+	return t.In(Istanbul).Format(layout)
+}
+
+// Equal reports whether t and u are the same instant, whatever their
+// layouts.
+func (t Timestamp) Equal(u Timestamp) bool {
+	return t.Time.Equal(u.Time)
+}
+
+// AppendText appends t as a KAP timestamp, as String does. It and
+// MarshalText replace the RFC 3339 form of time.Time, so that map keys
+// and text encoders agree with MarshalJSON.
+func (t Timestamp) AppendText(b []byte) ([]byte, error) {
+	return append(b, t.String()...), nil
+}
+
+// MarshalText encodes t as a KAP timestamp, as String does.
+func (t Timestamp) MarshalText() ([]byte, error) {
+	return t.AppendText(nil)
+}
+
+// UnmarshalText decodes a KAP timestamp. "" yields the zero Timestamp.
+func (t *Timestamp) UnmarshalText(data []byte) error {
+	if len(data) == 0 {
+		*t = Timestamp{}
+		return nil
+	}
+	parsed, err := ParseTimestamp(string(data))
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// MarshalJSON encodes t as a KAP timestamp string.
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// UnmarshalJSON decodes a KAP timestamp string. Null and "" yield the
+// zero Timestamp.
+func (t *Timestamp) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*t = Timestamp{}
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("kap: invalid timestamp %s: %w", data, err)
+	}
+	return t.UnmarshalText([]byte(s))
+}
@@ -0,0 +1,85 @@
+package kap
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestTimestampJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{`"29.10.2023 18:45:07"`, time.Date(2023, 10, 29, 18, 45, 7, 0, Istanbul)},
+		{`"01/02/2024 09:00:00"`, time.Date(2024, 2, 1, 9, 0, 0, 0, Istanbul)},
+		// Before 2016 Turkey observed daylight saving time.
+		{`"01.07.2015 12:00:00"`, time.Date(2015, 7, 1, 9, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		var ts Timestamp
+		if err := json.Unmarshal([]byte(tt.in), &ts); err != nil {
+			t.Errorf("Unmarshal(%s): %v", tt.in, err)
+			continue
+		}
+		if !ts.Time.Equal(tt.want) || ts.Location() != Istanbul {
+			t.Errorf("Unmarshal(%s) = %v, want %v in Istanbul", tt.in, ts.Time, tt.want)
+		}
+		out, err := json.Marshal(ts)
+		if err != nil || string(out) != tt.in {
+			t.Errorf("Marshal(Unmarshal(%s)) = %s, %v", tt.in, out, err)
+		}
+	}
+
+	for _, in := range []string{`null`, `""`} {
+		ts := Timestamp{Time: time.Now()}
+		if err := json.Unmarshal([]byte(in), &ts); err != nil || !ts.IsZero() {
+			t.Errorf("Unmarshal(%s) = %v, %v; want zero", in, ts, err)
+		}
+	}
+	for _, in := range []string{`"2023-10-29T18:45:07Z"`, `"32.10.2023 18:45:07"`, `1698594307`} {
+		var ts Timestamp
+		if err := json.Unmarshal([]byte(in), &ts); err == nil {
+			t.Errorf("Unmarshal(%s) = %v, want error", in, ts)
+		}
+	}
+}
+
+func TestTimestampText(t *testing.T) {
+	ts, err := ParseTimestamp("29/10/2023 18:45:07")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Map keys use MarshalText, which must agree with MarshalJSON.
+	out, err := json.Marshal(map[Timestamp]int{ts: 1})
+	if err != nil || string(out) != `{"29/10/2023 18:45:07":1}` {
+		t.Errorf("Marshal(map) = %s, %v", out, err)
+	}
+	var m map[Timestamp]int
+	if err := json.Unmarshal(out, &m); err != nil || m[ts] != 1 {
+		t.Errorf("Unmarshal(map) = %v, %v", m, err)
+	}
+
+	var back Timestamp
+	if err := back.UnmarshalText([]byte(ts.String())); err != nil || back.String() != ts.String() {
+		t.Errorf("UnmarshalText(String()) = %v, %v", back, err)
+	}
+}
+
+func TestTimestampEqual(t *testing.T) {
+	dot, _ := ParseTimestamp("29.10.2023 18:45:07")
+	slash, _ := ParseTimestamp("29/10/2023 18:45:07")
+	if !dot.Equal(slash) {
+		t.Error("timestamps of the same instant in different layouts are not Equal")
+	}
+	if dot.Equal(Timestamp{Time: dot.Add(time.Second)}) {
+		t.Error("different instants are Equal")
+	}
+	if got := (Timestamp{Time: dot.UTC()}).String(); got != "29.10.2023 18:45:07" {
+		t.Errorf("String of a UTC time = %q, want it in Istanbul", got)
+	}
+	if got := (Timestamp{}).String(); got != "" {
+		t.Errorf("zero String = %q", got)
+	}
+}
@@ -0,0 +1,110 @@
+package kap
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Token is a bearer token together with the validity window read from its
+// JWT claims.
+type Token struct {
+	// Raw is the token exactly as returned by GenerateToken. It is sent
+	// as-is in the Authorization header.
+	Raw string `json:"token"`
+
+	// IssuedAt is the "iat" claim. It is zero if the claim is missing.
+	IssuedAt time.Time `json:"issuedAt,omitzero"`
+
+	// ExpiresAt is the "exp" claim. It is zero if the expiry is unknown.
+	ExpiresAt time.Time `json:"expiresAt,omitzero"`
+}
+
+// ParseToken decodes the claims of a JWT bearer token. The signature is
+// not verified; the claims are only used to schedule token refreshes.
+// On error the returned Token still carries raw with zero timestamps.
+func ParseToken(raw string) (Token, error) {
+	t := Token{Raw: raw}
+
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return t, errors.New("kap: token is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return t, fmt.Errorf("kap: decoding token claims: %w", err)
+	}
+
+	var claims struct {
+		IssuedAt  *json.Number `json:"iat"`
+		ExpiresAt *json.Number `json:"exp"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return t, fmt.Errorf("kap: decoding token claims: %w", err)
+	}
+
+	if t.IssuedAt, err = claimTime(claims.IssuedAt); err != nil {
+		return t, fmt.Errorf("kap: decoding iat claim: %w", err)
+	}
+	if t.ExpiresAt, err = claimTime(claims.ExpiresAt); err != nil {
+		return t, fmt.Errorf("kap: decoding exp claim: %w", err)
+	}
+	return t, nil
+}
+
+// claimTime converts a NumericDate claim to a time.Time.
+func claimTime(n *json.Number) (time.Time, error) {
+	if n == nil {
+		return time.Time{}, nil
+	}
+	secs, err := n.Float64()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(int64(secs), 0), nil
+}
+
+// String returns the raw token.
+func (t Token) String() string {
+	return t.Raw
+}
+
+// IsZero reports whether t holds no token.
+func (t Token) IsZero() bool {
+	return t.Raw == ""
+}
+
+// Expired reports whether the token has a known expiry that has passed.
+func (t Token) Expired() bool {
+	return t.expiresWithin(0)
+}
+
+// expiresWithin reports whether the token has a known expiry less than d
+// from now.
+func (t Token) expiresWithin(d time.Duration) bool {
+	return !t.ExpiresAt.IsZero() && time.Until(t.ExpiresAt) <= d
+}
+
+// Token returns the bearer token currently held by the client. The zero
+// Token is returned if none has been set or generated yet.
+func (c *Client) Token() Token {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.token
+}
+
+// newToken parses raw and fills in a 24-hour expiry when the claims do not
+// carry one.
+func newToken(raw string, now time.Time) Token {
+	t, _ := ParseToken(raw)
+	if t.ExpiresAt.IsZero() {
+		if t.IssuedAt.IsZero() {
+			t.IssuedAt = now
+		}
+		t.ExpiresAt = t.IssuedAt.Add(tokenLifetime)
+	}
+	return t
+}
@@ -0,0 +1,90 @@
+package kap
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestParseToken(t *testing.T) {
+	claims := func(s string) string {
+		return "e30." + base64.RawURLEncoding.EncodeToString([]byte(s)) + ".sig"
+	}
+	tests := []struct {
+		name     string
+		raw      string
+		iat, exp int64
+		wantErr  bool
+	}{
+		{"both claims", claims(`{"iat":1700000000,"exp":1700086400}`), 1700000000, 1700086400, false},
+		{"fractional exp", claims(`{"exp":1700086400.5}`), 0, 1700086400, false},
+		{"no claims", claims(`{}`), 0, 0, false},
+		{"padded payload", "e30." + base64.URLEncoding.EncodeToString([]byte(`{"exp":1700086400}`)) + ".sig", 0, 1700086400, false},
+		{"not a JWT", "opaque-token", 0, 0, true},
+		{"bad base64", "e30.!!!.sig", 0, 0, true},
+		{"bad JSON", claims(`[1]`), 0, 0, true},
+		{"bad exp", claims(`{"exp":"soon"}`), 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tok, err := ParseToken(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseToken error = %v, want error %v", err, tt.wantErr)
+			}
+			if tok.Raw != tt.raw {
+				t.Errorf("Raw = %q, want %q", tok.Raw, tt.raw)
+			}
+			if got := unixOrZero(tok.IssuedAt); got != tt.iat {
+				t.Errorf("IssuedAt = %d, want %d", got, tt.iat)
+			}
+			if got := unixOrZero(tok.ExpiresAt); got != tt.exp {
+				t.Errorf("ExpiresAt = %d, want %d", got, tt.exp)
+			}
+		})
+	}
+}
+
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func TestWithToken(t *testing.T) {
+	// Both are plain option constructors.
+	var (
+		_ func(string) Option = WithToken
+		_ func(Token) Option  = WithTokenValue
+	)
+
+	exp := time.Now().Add(time.Hour).Truncate(time.Second)
+	raw := jwt(t, exp)
+	if got := NewClient("key", WithToken(raw)).Token(); got.Raw != raw || !got.ExpiresAt.Equal(exp) {
+		t.Errorf("WithToken: Token() = %+v, want expiry %v", got, exp)
+	}
+	if got := NewClient("key", WithToken("opaque")).Token(); got.Raw != "opaque" || !got.ExpiresAt.IsZero() {
+		t.Errorf("WithToken(opaque): Token() = %+v", got)
+	}
+
+	tok := Token{Raw: "opaque", ExpiresAt: exp}
+	if got := NewClient("key", WithTokenValue(tok)).Token(); got != tok {
+		t.Errorf("WithTokenValue: Token() = %+v, want %+v", got, tok)
+	}
+}
+
+func TestTokenExpiry(t *testing.T) {
+	if (Token{Raw: "x"}).Expired() {
+		t.Error("token without expiry reported expired")
+	}
+	if !(Token{Raw: "x", ExpiresAt: time.Now().Add(-time.Second)}).Expired() {
+		t.Error("past expiry not reported expired")
+	}
+	tok := newToken("opaque", time.Unix(1700000000, 0))
+	if !tok.ExpiresAt.Equal(time.Unix(1700000000, 0).Add(tokenLifetime)) {
+		t.Errorf("newToken without claims expires at %v, want 24 hours after issue", tok.ExpiresAt)
+	}
+	if !(Token{}).IsZero() || tok.IsZero() || tok.String() != "opaque" {
+		t.Errorf("IsZero/String of %+v", tok)
+	}
+}
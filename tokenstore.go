@@ -0,0 +1,180 @@
+package kap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// TokenStore shares bearer tokens between clients, typically across
+// processes, so that a fleet of workers reuses one valid token instead of
+// each generating its own.
+//
+// Before generating a token, a Client loads the stored token and uses it if
+// it is still valid. Otherwise it takes the store lock, loads again in case
+// another client refreshed it meanwhile, and only then generates and saves a
+// new token.
+type TokenStore interface {
+	// Load returns the stored token, or the zero Token if none is stored.
+	Load(ctx context.Context) (Token, error)
+
+	// Save stores t, replacing any previous token.
+	Save(ctx context.Context, t Token) error
+
+	// Lock blocks until the caller holds the store's exclusive lock or ctx
+	// is done. The returned function releases the lock.
+	Lock(ctx context.Context) (unlock func(), err error)
+}
+
+// MemoryTokenStore is a TokenStore that keeps the token in memory. It lets
+// several clients within one process share a token.
+type MemoryTokenStore struct {
+	mu    sync.Mutex // protects token
+	token Token
+	sem   chan struct{}
+}
+
+// NewMemoryTokenStore creates an empty in-memory token store.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{sem: make(chan struct{}, 1)}
+}
+
+// Load returns the stored token.
+func (s *MemoryTokenStore) Load(_ context.Context) (Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.token, nil
+}
+
+// Save stores t.
+func (s *MemoryTokenStore) Save(_ context.Context, t Token) error {
+	s.mu.Lock()
+	s.token = t
+	s.mu.Unlock()
+	return nil
+}
+
+// Lock acquires the store lock.
+func (s *MemoryTokenStore) Lock(ctx context.Context) (func(), error) {
+	select {
+	case s.sem <- struct{}{}:
+		return func() { <-s.sem }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// FileTokenStore is a TokenStore backed by a JSON file, shared by every
+// process that points at the same path. Writes replace the file atomically
+// via rename, and Lock takes an advisory lock on a sibling ".lock" file.
+type FileTokenStore struct {
+	path string
+}
+
+// NewFileTokenStore creates a token store that keeps the token in the file
+// at path. The file and its directory are created on first Save.
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{path: path}
+}
+
+// Load reads the token file. A missing file yields the zero Token.
+func (s *FileTokenStore) Load(_ context.Context) (Token, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Token{}, nil
+	}
+	if err != nil {
+		return Token{}, fmt.Errorf("kap: reading token file: %w", err)
+	}
+
+	var t Token
+	if err := json.Unmarshal(data, &t); err != nil {
+		return Token{}, fmt.Errorf("kap: decoding token file: %w", err)
+	}
+	return t, nil
+}
+
+// Save writes t to a temporary file and renames it over the token file, so
+// readers never observe a partial write.
+func (s *FileTokenStore) Save(_ context.Context, t Token) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("kap: encoding token file: %w", err)
+	}
+	if err := writeFileAtomic(s.path, data, 0o600); err != nil {
+		return fmt.Errorf("kap: writing token file: %w", err)
+	}
+	return nil
+}
+
+// Lock takes an exclusive advisory lock on the token file's ".lock"
+// sibling, polling until it is acquired or ctx is done.
+func (s *FileTokenStore) Lock(ctx context.Context) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return nil, fmt.Errorf("kap: creating token directory: %w", err)
+	}
+	f, err := os.OpenFile(s.path+".lock", os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("kap: opening token lock file: %w", err)
+	}
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		ok, err := tryLockFile(f)
+		if err != nil {
+			f.Close() //nolint:errcheck // lock file close error is not actionable
+			return nil, fmt.Errorf("kap: locking token file: %w", err)
+		}
+		if ok {
+			return func() {
+				unlockFile(f) //nolint:errcheck // closing the file releases the lock anyway
+				f.Close()     //nolint:errcheck // lock file close error is not actionable
+			}, nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			f.Close() //nolint:errcheck // lock file close error is not actionable
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory as
+// path, syncs it, and renames it over path.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // the file is gone after a successful rename
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close() //nolint:errcheck // the write error takes precedence
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close() //nolint:errcheck // the sync error takes precedence
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
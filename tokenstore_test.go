@@ -0,0 +1,137 @@
+package kap
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFileTokenStoreRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	s := NewFileTokenStore(filepath.Join(t.TempDir(), "dir", "token.json"))
+
+	got, err := s.Load(ctx)
+	if err != nil || !got.IsZero() {
+		t.Fatalf("Load of missing file = %v, %v; want zero token", got, err)
+	}
+
+	want := Token{Raw: "abc", IssuedAt: time.Unix(1700000000, 0).UTC(), ExpiresAt: time.Unix(1700086400, 0).UTC()}
+	if err := s.Save(ctx, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err = s.Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Raw != want.Raw || !got.IssuedAt.Equal(want.IssuedAt) || !got.ExpiresAt.Equal(want.ExpiresAt) {
+		t.Errorf("Load = %+v, want %+v", got, want)
+	}
+}
+
+func TestTokenStoreLockExclusive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "token.json")
+	stores := map[string][2]TokenStore{
+		"memory": func() [2]TokenStore { s := NewMemoryTokenStore(); return [2]TokenStore{s, s} }(),
+		// Two stores on one path stand in for two processes.
+		"file": {NewFileTokenStore(path), NewFileTokenStore(path)},
+	}
+	for name, pair := range stores {
+		t.Run(name, func(t *testing.T) {
+			unlock, err := pair[0].Lock(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+			defer cancel()
+			if _, err := pair[1].Lock(ctx); !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("second Lock while held: err = %v, want deadline exceeded", err)
+			}
+
+			var acquired atomic.Bool
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				unlock2, err := pair[1].Lock(context.Background())
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				acquired.Store(true)
+				unlock2()
+			}()
+			time.Sleep(100 * time.Millisecond)
+			if acquired.Load() {
+				t.Fatal("second Lock acquired while the first was held")
+			}
+			unlock()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("second Lock not acquired after unlock")
+			}
+		})
+	}
+}
+
+func TestFileTokenStoreNoPartialWrites(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "token.json")
+	s := NewFileTokenStore(path)
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	defer func() {
+		close(stop)
+		<-stopped
+	}()
+	go func() {
+		defer close(stopped)
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			s.Save(ctx, Token{Raw: string(rune('a' + i%26))}) //nolint:errcheck // reader reports failures
+		}
+	}()
+	for range 200 {
+		if _, err := s.Load(ctx); err != nil && !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("Load during Save: %v", err)
+		}
+	}
+}
+
+func TestTokenStoreSharedBetweenClients(t *testing.T) {
+	stores := map[string]func(t *testing.T) TokenStore{
+		"memory": func(*testing.T) TokenStore { return NewMemoryTokenStore() },
+		"file":   func(t *testing.T) TokenStore { return NewFileTokenStore(t.TempDir() + "/token.json") },
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			srv := &tokenServer{delay: 20 * time.Millisecond}
+			url := newServer(t, srv.handler)
+			store := newStore(t)
+			clients := make([]*Client, 5)
+			for i := range clients {
+				clients[i] = NewClient("key", WithBaseURL(url), WithTokenStore(store))
+			}
+
+			var n atomic.Int32
+			runConcurrently(20, func() {
+				c := clients[n.Add(1)%int32(len(clients))]
+				if _, err := c.LastDisclosureIndex(context.Background()); err != nil {
+					t.Error(err)
+				}
+			})
+			if got := srv.generated.Load(); got != 1 {
+				t.Errorf("generated %d tokens, want 1", got)
+			}
+		})
+	}
+}
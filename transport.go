@@ -2,26 +2,25 @@ package kap
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"time"
 )
 
 // get performs an authenticated GET request and decodes the JSON response
-// into dest.
-func (c *Client) get(ctx context.Context, path string, params url.Values, dest interface{}) error {
-	resp, err := c.doRequest(ctx, path, params)
+// into dest. op is the name of the Client method making the call.
+func (c *Client) get(ctx context.Context, op, path string, params url.Values, dest interface{}) error {
+	resp, err := c.doRequest(ctx, op, path, params)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close() //nolint:errcheck // response body close error is not actionable
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return c.handleErrorResponse(resp, path)
-	}
-
 	if err := json.NewDecoder(resp.Body).Decode(dest); err != nil {
 		return &RequestError{Method: http.MethodGet, Path: path, Err: fmt.Errorf("decoding response: %w", err)}
 	}
@@ -31,68 +30,172 @@ func (c *Client) get(ctx context.Context, path string, params url.Values, dest i
 // getRaw performs an authenticated GET request and returns the raw response
 // body along with the Content-Disposition header value. The caller is
 // responsible for closing the returned ReadCloser.
-func (c *Client) getRaw(ctx context.Context, path string, params url.Values) (io.ReadCloser, string, error) {
-	resp, err := c.doRequest(ctx, path, params)
+func (c *Client) getRaw(ctx context.Context, op, path string, params url.Values) (io.ReadCloser, string, error) {
+	resp, err := c.doRequest(ctx, op, path, params)
 	if err != nil {
 		return nil, "", err
 	}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		defer resp.Body.Close() //nolint:errcheck // response body close error is not actionable
-		return nil, "", c.handleErrorResponse(resp, path)
-	}
-
 	contentDisposition := resp.Header.Get("Content-Disposition")
 	return resp.Body, contentDisposition, nil
 }
 
-// doRequest builds and executes an authenticated HTTP GET request.
-func (c *Client) doRequest(ctx context.Context, path string, params url.Values) (*http.Response, error) {
-	reqURL := c.baseURL + path
-	if len(params) > 0 {
-		reqURL += "?" + params.Encode()
+// doRequest executes an authenticated HTTP GET request and returns the
+// response if it has a 2xx status, or an *APIError or *RequestError
+// otherwise. Failed attempts are retried according to the client's retry
+// policy.
+func (c *Client) doRequest(ctx context.Context, op, path string, params url.Values) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		req := &Request{
+			Operation: op,
+			Path:      path,
+			Params:    params,
+			Header:    make(http.Header),
+			Attempt:   attempt,
+		}
+		resp, err := c.attempt(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+
+		wait, ok := c.retryPolicy.next(ctx, attempt, retryAfter(err), err)
+		if !ok {
+			return nil, err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, err
+		}
 	}
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+// attempt authorizes req and passes it through the middleware chain. When
+// the client manages its own bearer token and the API rejects it, the
+// token is regenerated once and the request is replayed.
+func (c *Client) attempt(ctx context.Context, req *Request) (*http.Response, error) {
+	token, err := c.authorize(ctx, req)
 	if err != nil {
-		return nil, &RequestError{Method: http.MethodGet, Path: path, Err: err}
+		return nil, err
+	}
+	resp, err := c.handler(ctx, req)
+	if err == nil || token == "" || !c.canRefreshToken() || !isTokenError(err) {
+		return resp, err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	if err := c.renewToken(ctx, token); err != nil {
+		return nil, err
+	}
+	if _, err := c.authorize(ctx, req); err != nil {
+		return nil, err
+	}
+	return c.handler(ctx, req)
+}
 
+// authorize sets the Authorization header on req. It returns the bearer
+// token that was set, if any.
+func (c *Client) authorize(ctx context.Context, req *Request) (string, error) {
 	if c.basicAuth != nil {
-		req.SetBasicAuth(c.basicAuth.Username, c.basicAuth.Password)
-	} else {
-		c.mu.RLock()
-		token := c.token
-		c.mu.RUnlock()
-		if token != "" {
-			req.Header.Set("Authorization", token)
-		}
+		creds := c.basicAuth.Username + ":" + c.basicAuth.Password
+		req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(creds)))
+		return "", nil
+	}
+	if req.Path == generateTokenPath {
+		return "", nil
 	}
 
-	resp, err := c.httpClient.Do(req)
+	token, err := c.bearerToken(ctx)
 	if err != nil {
-		return nil, &RequestError{Method: http.MethodGet, Path: path, Err: err}
+		return "", err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+	return token, nil
+}
+
+// roundTrip is the innermost Handler. It waits for the rate limiter, sends
+// req, and converts non-2xx responses into errors.
+func (c *Client) roundTrip(ctx context.Context, req *Request) (*http.Response, error) {
+	reqURL := c.baseURL + req.Path
+	if len(req.Params) > 0 {
+		reqURL += "?" + req.Params.Encode()
+	}
+
+	hreq, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, &RequestError{Method: http.MethodGet, Path: req.Path, Err: err}
+	}
+
+	for k, v := range req.Header {
+		hreq.Header[k] = v
+	}
+	hreq.Header.Set("Content-Type", "application/json")
+
+	if err := c.waitRateLimit(ctx, req.Path); err != nil {
+		return nil, &RequestError{Method: http.MethodGet, Path: req.Path, Err: err}
+	}
+
+	resp, err := c.httpClient.Do(hreq)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = redactSecrets(urlErr.URL)
+		}
+		return nil, &RequestError{Method: http.MethodGet, Path: req.Path, Err: err}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, c.handleErrorResponse(resp, req.Path)
 	}
 	return resp, nil
 }
 
-// handleErrorResponse reads the response body and returns an *APIError.
+// handleErrorResponse reads and closes the response body and returns an
+// *APIError, or a *RequestError if the body is not a KAP error object.
 func (c *Client) handleErrorResponse(resp *http.Response, path string) error {
+	defer resp.Body.Close() //nolint:errcheck // response body close error is not actionable
+
+	wait := parseRetryAfter(resp.Header, time.Now())
+
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return &RequestError{Method: http.MethodGet, Path: path, Err: fmt.Errorf("reading error response: %w", err)}
+		return &RequestError{
+			Method:     http.MethodGet,
+			Path:       path,
+			HTTPStatus: resp.StatusCode,
+			Err:        fmt.Errorf("reading error response: %w", err),
+			retryAfter: wait,
+		}
 	}
 
 	var apiErr APIError
 	if err := json.Unmarshal(body, &apiErr); err != nil {
 		return &RequestError{
-			Method: http.MethodGet,
-			Path:   path,
-			Err:    fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body)),
+			Method:     http.MethodGet,
+			Path:       path,
+			HTTPStatus: resp.StatusCode,
+			Err:        fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body)),
+			retryAfter: wait,
 		}
 	}
 	apiErr.HTTPStatus = resp.StatusCode
+	apiErr.retryAfter = wait
 	return &apiErr
 }
+
+// retryAfter returns the delay requested by the Retry-After header of the
+// response that caused err, if any.
+func retryAfter(err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.retryAfter
+	}
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.retryAfter
+	}
+	return 0
+}
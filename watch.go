@@ -0,0 +1,227 @@
+package kap
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	// DefaultPollInterval is how often a Watcher polls for new disclosures
+	// when WatcherOptions.PollInterval is zero.
+	DefaultPollInterval = 5 * time.Second
+
+	// DefaultMaxPollInterval caps the idle backoff of a Watcher when
+	// WatcherOptions.MaxPollInterval is zero.
+	DefaultMaxPollInterval = time.Minute
+)
+
+// WatcherOptions configures a Watcher. The zero value is usable.
+type WatcherOptions struct {
+	// Params filters the disclosures delivered, as for Disclosures.
+	Params *DisclosureListParams
+
+	// PollInterval is the delay between polls while new disclosures keep
+	// arriving. Zero means DefaultPollInterval.
+	PollInterval time.Duration
+
+	// MaxPollInterval caps the delay between polls. While no new
+	// disclosures arrive the delay doubles from PollInterval up to this
+	// value. Zero means DefaultMaxPollInterval.
+	MaxPollInterval time.Duration
+
+	// Start is the first disclosure index to deliver. When zero, the
+	// watcher resumes after the index saved in Checkpoint, or, without a
+	// saved index, delivers only disclosures published after it starts.
+	Start int
+
+	// Checkpoint, if set, persists the high-water mark after every
+	// delivered disclosure.
+	Checkpoint Checkpoint
+
+	// OnGap, if set, is called when unfiltered polling delivers a
+	// disclosure and finds the indices between from and to (inclusive)
+	// before it missing from the disclosure list, for example because
+	// they were blocked.
+	OnGap func(from, to int)
+
+	// OnError, if set, is called when a poll fails; the watcher then waits
+	// for the next poll. Without OnError, Run returns the error.
+	OnError func(err error)
+}
+
+// Watcher polls LastDisclosureIndex and delivers each new disclosure once,
+// in index order.
+type Watcher struct {
+	client  *Client
+	opts    WatcherOptions
+	hwm     int
+	seen    int  // highest LastDisclosureIndex seen
+	started bool // hwm has been initialized
+}
+
+// NewWatcher creates a Watcher. opts may be nil.
+func (c *Client) NewWatcher(opts *WatcherOptions) *Watcher {
+	w := &Watcher{client: c}
+	if opts != nil {
+		w.opts = *opts
+	}
+	if w.opts.PollInterval <= 0 {
+		w.opts.PollInterval = DefaultPollInterval
+	}
+	if w.opts.MaxPollInterval <= 0 {
+		w.opts.MaxPollInterval = DefaultMaxPollInterval
+	}
+	w.opts.MaxPollInterval = max(w.opts.MaxPollInterval, w.opts.PollInterval)
+	return w
+}
+
+// HighWaterMark returns the highest disclosure index the watcher has
+// delivered, or the index it started after. Disclosures above it have not
+// been delivered yet.
+func (w *Watcher) HighWaterMark() int {
+	return w.hwm
+}
+
+// Run polls until ctx is done, calling fn for each new disclosure. A
+// disclosure counts as delivered once fn returns nil; if fn returns an
+// error, Run stops and returns it without advancing past that disclosure,
+// so it is delivered again on the next Run. Run returns ctx.Err() when ctx
+// is done.
+//
+// The first Run starts as WatcherOptions.Start describes; later calls
+// resume from the high-water mark.
+func (w *Watcher) Run(ctx context.Context, fn func(Disclosure) error) error {
+	if err := w.init(ctx); err != nil {
+		return err
+	}
+
+	interval := w.opts.PollInterval
+	for {
+		found, err := w.poll(ctx, fn)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		var herr handlerError
+		if errors.As(err, &herr) {
+			return herr.err
+		}
+		if err != nil {
+			if w.opts.OnError == nil {
+				return err
+			}
+			w.opts.OnError(err)
+		}
+
+		if found {
+			interval = w.opts.PollInterval
+		} else {
+			interval = min(interval*2, w.opts.MaxPollInterval)
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// init sets the initial high-water mark, unless the watcher has already
+// started.
+func (w *Watcher) init(ctx context.Context) error {
+	if w.started {
+		return nil
+	}
+	hwm, err := w.initialMark(ctx)
+	if err != nil {
+		return err
+	}
+	w.hwm, w.seen, w.started = hwm, hwm, true
+	return nil
+}
+
+// initialMark returns the index the watcher starts after.
+func (w *Watcher) initialMark(ctx context.Context) (int, error) {
+	if w.opts.Start > 0 {
+		return w.opts.Start - 1, nil
+	}
+	if w.opts.Checkpoint != nil {
+		saved, err := w.opts.Checkpoint.Load(ctx)
+		if err != nil {
+			return 0, err
+		}
+		if saved > 0 {
+			return saved, nil
+		}
+	}
+	return w.client.LastDisclosureIndex(ctx)
+}
+
+// poll delivers every disclosure listed since the high-water mark. It
+// reports whether the latest index had advanced since the previous poll.
+//
+// The high-water mark only advances to disclosures actually delivered:
+// LastDisclosureIndex can run ahead of the disclosure list, so indices up
+// to last that are not listed yet are asked for again on the next poll.
+func (w *Watcher) poll(ctx context.Context, fn func(Disclosure) error) (bool, error) {
+	last, err := w.client.LastDisclosureIndex(ctx)
+	if err != nil {
+		return false, err
+	}
+	if last <= w.hwm {
+		return false, nil
+	}
+	found := last > w.seen
+	w.seen = max(w.seen, last)
+
+	prev := w.hwm
+	pager := w.client.DisclosurePager(w.hwm+1, last, w.opts.Params)
+	for d, err := range pager.All(ctx) {
+		if err != nil {
+			return found, err
+		}
+		idx := int(d.DisclosureIndex)
+		if idx <= w.hwm {
+			continue
+		}
+		w.reportGap(prev+1, idx-1)
+		prev = idx
+
+		if err := fn(d); err != nil {
+			return found, handlerError{err}
+		}
+		if err := w.advance(ctx, idx); err != nil {
+			return found, err
+		}
+	}
+	return found, nil
+}
+
+// advance moves the high-water mark to index and saves it.
+func (w *Watcher) advance(ctx context.Context, index int) error {
+	if index <= w.hwm {
+		return nil
+	}
+	w.hwm = index
+	if w.opts.Checkpoint != nil {
+		return w.opts.Checkpoint.Save(ctx, index)
+	}
+	return nil
+}
+
+// reportGap calls OnGap for a non-empty range when polling is unfiltered.
+func (w *Watcher) reportGap(from, to int) {
+	if from > to || w.opts.OnGap == nil || !w.opts.Params.isZero() {
+		return
+	}
+	w.opts.OnGap(from, to)
+}
+
+// handlerError marks errors returned by the caller's callback so they are
+// never passed to OnError.
+type handlerError struct{ err error }
+
+func (e handlerError) Error() string { return e.err.Error() }
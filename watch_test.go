@@ -0,0 +1,147 @@
+package kap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// watchServer serves LastDisclosureIndex and the disclosure list. The
+// state is set with set or, if next is set, by next before each
+// LastDisclosureIndex response, given the number of calls so far.
+type watchServer struct {
+	mu     sync.Mutex
+	calls  int
+	last   int
+	listed []int
+	next   func(calls int) (last int, listed []int)
+}
+
+func (s *watchServer) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if strings.HasSuffix(r.URL.Path, "/lastDisclosureIndex") {
+		s.calls++
+		if s.next != nil {
+			s.last, s.listed = s.next(s.calls)
+		}
+		fmt.Fprintf(w, `{"lastDisclosureIndex":"%d"}`, s.last)
+		return
+	}
+	from, _ := strconv.Atoi(r.URL.Query().Get("disclosureIndex"))
+	items := []string{}
+	for _, idx := range s.listed {
+		if idx >= from {
+			items = append(items, fmt.Sprintf(`{"disclosureIndex":"%d"}`, idx))
+		}
+	}
+	fmt.Fprintf(w, "[%s]", strings.Join(items, ","))
+}
+
+func (s *watchServer) set(last int, listed ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.last, s.listed = last, listed
+}
+
+// TestWatcherListLag checks that indices LastDisclosureIndex reports
+// before the list has them are delivered by a later poll, not skipped.
+func TestWatcherListLag(t *testing.T) {
+	srv := &watchServer{next: func(calls int) (int, []int) {
+		switch calls {
+		case 1: // 103 is counted but not listed yet.
+			return 103, []int{101, 102}
+		case 2:
+			return 103, []int{101, 102, 103}
+		}
+		// 104 is never listed, for example because it is blocked.
+		return 105, []int{101, 102, 103, 105}
+	}}
+	c := newTestClient(t, srv.handle)
+
+	var gaps [][2]int
+	w := c.NewWatcher(&WatcherOptions{
+		Start:        101,
+		PollInterval: time.Millisecond,
+		OnGap:        func(from, to int) { gaps = append(gaps, [2]int{from, to}) },
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var delivered []int
+	err := w.Run(ctx, func(d Disclosure) error {
+		delivered = append(delivered, int(d.DisclosureIndex))
+		if d.DisclosureIndex == 105 {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Run error = %v", err)
+	}
+	if !slices.Equal(delivered, []int{101, 102, 103, 105}) {
+		t.Errorf("delivered %v, want [101 102 103 105]", delivered)
+	}
+	if len(gaps) != 1 || gaps[0] != [2]int{104, 104} {
+		t.Errorf("gaps = %v, want [[104 104]]", gaps)
+	}
+}
+
+// TestWatcherRunResumes checks that a disclosure whose handler failed is
+// delivered again by the next Run, and nothing else is.
+func TestWatcherRunResumes(t *testing.T) {
+	errHandler := errors.New("handler failed")
+	for _, start := range []int{101, 0} {
+		t.Run(fmt.Sprintf("Start=%d", start), func(t *testing.T) {
+			srv := &watchServer{}
+			srv.set(105, 101, 102, 103, 104, 105)
+			if start == 0 {
+				// The watcher starts after the latest index, which then
+				// moves on.
+				srv.next = func(calls int) (int, []int) {
+					if calls == 1 {
+						return 100, nil
+					}
+					return 105, []int{101, 102, 103, 104, 105}
+				}
+			}
+			w := newTestClient(t, srv.handle).NewWatcher(&WatcherOptions{Start: start, PollInterval: time.Millisecond})
+
+			var delivered []int
+			failed := false
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			fn := func(d Disclosure) error {
+				idx := int(d.DisclosureIndex)
+				if idx == 103 && !failed {
+					failed = true
+					return errHandler
+				}
+				delivered = append(delivered, idx)
+				if idx == 105 {
+					cancel()
+				}
+				return nil
+			}
+
+			if err := w.Run(ctx, fn); !errors.Is(err, errHandler) {
+				t.Fatalf("first Run error = %v, want the handler error", err)
+			}
+			if w.HighWaterMark() != 102 {
+				t.Errorf("high-water mark after failure = %d, want 102", w.HighWaterMark())
+			}
+			if err := w.Run(ctx, fn); !errors.Is(err, context.Canceled) {
+				t.Fatalf("second Run error = %v", err)
+			}
+			if !slices.Equal(delivered, []int{101, 102, 103, 104, 105}) {
+				t.Errorf("delivered %v, want [101 102 103 104 105]", delivered)
+			}
+		})
+	}
+}